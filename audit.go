@@ -0,0 +1,62 @@
+package voiceit2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// AuditRecord is the outcome of a verification or identification. It
+// identifies the media by its SHA-256 but never contains it
+type AuditRecord struct {
+	// Method is the client method called, for example "VoiceVerification"
+	Method string `json:"method"`
+	// UserId is the verified user, or the user identified in GroupId
+	UserId          string `json:"userId,omitempty"`
+	GroupId         string `json:"groupId,omitempty"`
+	ContentLanguage string `json:"contentLanguage,omitempty"`
+	ResponseCode    string `json:"responseCode"`
+	// Confidences are set when the reply contains them
+	Confidence      *float64  `json:"confidence,omitempty"`
+	VoiceConfidence *float64  `json:"voiceConfidence,omitempty"`
+	FaceConfidence  *float64  `json:"faceConfidence,omitempty"`
+	Time            time.Time `json:"time"`
+	// MediaSHA256 is the hex SHA-256 of an uploaded file. Calls by URL record
+	// MediaUrl instead, as the media never passes through the client
+	MediaSHA256 string `json:"mediaSha256,omitempty"`
+	MediaUrl    string `json:"mediaUrl,omitempty"`
+}
+
+// AuditSink receives the outcome of every verification and identification a
+// client makes. Calls that get no reply from the API have no outcome and are
+// not recorded
+type AuditSink interface {
+	Audit(record AuditRecord) error
+}
+
+// audit sends the outcome of a call to the client's AuditSink, if any
+func (vi VoiceIt2) audit(record AuditRecord, media []byte, reply []byte) error {
+	if vi.AuditSink == nil {
+		return nil
+	}
+	var r struct {
+		ResponseCode    string   `json:"responseCode"`
+		UserId          string   `json:"userId"`
+		Confidence      *float64 `json:"confidence"`
+		VoiceConfidence *float64 `json:"voiceConfidence"`
+		FaceConfidence  *float64 `json:"faceConfidence"`
+	}
+	json.Unmarshal(reply, &r)
+	record.ResponseCode = r.ResponseCode
+	if record.UserId == "" {
+		record.UserId = r.UserId
+	}
+	record.Confidence, record.VoiceConfidence, record.FaceConfidence = r.Confidence, r.VoiceConfidence, r.FaceConfidence
+	record.Time = time.Now().UTC()
+	if media != nil {
+		sum := sha256.Sum256(media)
+		record.MediaSHA256 = hex.EncodeToString(sum[:])
+	}
+	return vi.AuditSink.Audit(record)
+}
@@ -0,0 +1,178 @@
+// Package audit keeps a tamper-evident log of verification and identification
+// outcomes. Each entry holds the hash of the one before it, so editing,
+// removing or reordering entries breaks the chain and is found by Verify
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+)
+
+// Genesis is the Prev hash of the first entry of a log
+const Genesis = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Entry is one line of the log. Hash is the SHA-256 of Seq, Prev and Record
+type Entry struct {
+	Seq    uint64          `json:"seq"`
+	Prev   string          `json:"prev"`
+	Record json.RawMessage `json:"record"`
+	Hash   string          `json:"hash"`
+}
+
+func entryHash(seq uint64, prev string, record []byte) string {
+	h := sha256.New()
+	io.WriteString(h, strconv.FormatUint(seq, 10)+"\n"+prev+"\n")
+	h.Write(record)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Log is an AuditSink appending entries to an NDJSON file. It is safe for
+// concurrent use by several clients
+type Log struct {
+	mu   sync.Mutex
+	f    *os.File
+	seq  uint64
+	head string
+}
+
+// OpenLog opens the log at path for appending, creating it if it does not exist.
+// An existing log is verified first and not opened if its chain is broken, so
+// new entries never extend a tampered log into one that looks intact
+func OpenLog(path string) (*Log, error) {
+	l := &Log{head: Genesis}
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.New("OpenLog error: " + err.Error())
+	}
+	if len(data) > 0 {
+		if data[len(data)-1] != '\n' {
+			return nil, errors.New("OpenLog error: " + path + ": last entry is incomplete")
+		}
+		res, err := Verify(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.New("OpenLog error: " + path + ": " + err.Error())
+		}
+		if !res.OK() {
+			p := res.Problems[0]
+			return nil, errors.New("OpenLog error: " + path + ": line " + strconv.Itoa(p.Line) + ": " + p.Reason)
+		}
+		l.seq, l.head = res.Seq, res.Head
+	}
+	l.f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.New("OpenLog error: " + err.Error())
+	}
+	return l, nil
+}
+
+// Audit appends a record and syncs it to disk
+func (l *Log) Audit(record voiceit2.AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := Entry{Seq: l.seq + 1, Prev: l.head, Record: data}
+	e.Hash = entryHash(e.Seq, e.Prev, e.Record)
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := l.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+	l.seq, l.head = e.Seq, e.Hash
+	return nil
+}
+
+// Head returns the sequence number and hash of the last entry. Keeping them
+// outside the log, for example in a daily report, lets Verify callers detect
+// entries removed from the end, which the chain alone cannot show
+func (l *Log) Head() (uint64, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.seq, l.head
+}
+
+// Close closes the log file
+func (l *Log) Close() error {
+	return l.f.Close()
+}
+
+// Problem is a break in the chain found by Verify
+type Problem struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// Result is the outcome of verifying a log
+type Result struct {
+	Entries int `json:"entries"`
+	// Seq and Head are the sequence number and hash of the last entry
+	Seq      uint64    `json:"seq"`
+	Head     string    `json:"head"`
+	Problems []Problem `json:"problems"`
+}
+
+// OK reports whether the chain is intact
+func (r Result) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Verify checks every entry of a log. After a problem it continues from the
+// entry found, so each edit or gap is reported once. The error is only for
+// failures to read the log
+func Verify(r io.Reader) (*Result, error) {
+	res := &Result{Head: Genesis, Problems: []Problem{}}
+	seq, prev := uint64(0), Genesis
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			res.Problems = append(res.Problems, Problem{Line: line, Reason: "not a log entry: " + err.Error()})
+			continue
+		}
+		res.Entries++
+		switch {
+		case e.Seq != seq+1:
+			res.Problems = append(res.Problems, Problem{Line: line, Reason: "entries missing: expected entry " + strconv.FormatUint(seq+1, 10) + ", found " + strconv.FormatUint(e.Seq, 10)})
+		case e.Prev != prev:
+			res.Problems = append(res.Problems, Problem{Line: line, Reason: "chain broken: entry does not follow the previous one"})
+		}
+		if entryHash(e.Seq, e.Prev, e.Record) != e.Hash {
+			res.Problems = append(res.Problems, Problem{Line: line, Reason: "entry " + strconv.FormatUint(e.Seq, 10) + " was modified"})
+		}
+		seq, prev = e.Seq, e.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("Verify error: " + err.Error())
+	}
+	res.Seq, res.Head = seq, prev
+	return res, nil
+}
+
+// VerifyFile verifies the log at path
+func VerifyFile(path string) (*Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("VerifyFile error: " + err.Error())
+	}
+	defer f.Close()
+	return Verify(f)
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apitest"
+)
+
+func TestLog(t *testing.T) {
+	assert := assert.New(t)
+	api, myVoiceIt := apitest.New(t)
+	dir, _ := ioutil.TempDir("", "audit")
+	defer os.RemoveAll(dir)
+	sample := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		ioutil.WriteFile(path, []byte(content), 0600)
+		return path
+	}
+	phrase := "never forget tomorrow is a new day"
+	path := filepath.Join(dir, "audit.log")
+
+	log, err := OpenLog(path)
+	assert.Equal(nil, err)
+	myVoiceIt.AuditSink = log
+	userId := api.AddUser()
+	groupId := api.AddGroup("staff", userId)
+	for i := 0; i < 3; i++ {
+		myVoiceIt.CreateVoiceEnrollment(userId, "en-US", phrase, sample("enroll", "ann\n"+phrase))
+	}
+	_, err = myVoiceIt.VoiceVerification(userId, "en-US", phrase, sample("good", "ann\n"+phrase))
+	assert.Equal(nil, err)
+	myVoiceIt.VoiceVerification(userId, "en-US", phrase, sample("bad", "bob\n"+phrase))
+	myVoiceIt.VoiceIdentification(groupId, "en-US", phrase, sample("good", "ann\n"+phrase))
+	log.Close()
+
+	// Reopening continues the chain
+	log, err = OpenLog(path)
+	assert.Equal(nil, err)
+	myVoiceIt.AuditSink = log
+	myVoiceIt.VoiceVerificationByUrl(userId, "en-US", phrase, "https://example.com/good.wav")
+	seq, head := log.Head()
+	log.Close()
+	assert.Equal(uint64(4), seq)
+
+	data, _ := ioutil.ReadFile(path)
+	assert.NotContains(string(data), "ann\\n", "media is never logged")
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(4, len(lines))
+	var records []voiceit2.AuditRecord
+	for _, line := range lines {
+		var e Entry
+		var r voiceit2.AuditRecord
+		json.Unmarshal([]byte(line), &e)
+		json.Unmarshal(e.Record, &r)
+		records = append(records, r)
+	}
+	sum := sha256.Sum256([]byte("ann\n" + phrase))
+	assert.Equal("VoiceVerification", records[0].Method)
+	assert.Equal(userId, records[0].UserId)
+	assert.Equal("SUCC", records[0].ResponseCode)
+	assert.Equal(95.0, *records[0].Confidence)
+	assert.Equal(hex.EncodeToString(sum[:]), records[0].MediaSHA256)
+	assert.False(records[0].Time.IsZero())
+	assert.Equal("FAIL", records[1].ResponseCode)
+	assert.Equal(groupId, records[2].GroupId)
+	assert.Equal(userId, records[2].UserId, "the identified user is recorded")
+	assert.Equal("", records[3].MediaSHA256)
+	assert.Equal("https://example.com/good.wav", records[3].MediaUrl)
+
+	res, err := VerifyFile(path)
+	assert.Equal(nil, err)
+	assert.True(res.OK(), res.Problems)
+	assert.Equal(4, res.Entries)
+	assert.Equal(head, res.Head)
+
+	verify := func(lines []string) *Result {
+		res, err := Verify(strings.NewReader(strings.Join(lines, "\n") + "\n"))
+		assert.Equal(nil, err)
+		return res
+	}
+	edited := append([]string(nil), lines...)
+	edited[1] = strings.Replace(edited[1], `"responseCode":"FAIL"`, `"responseCode":"SUCC"`, 1)
+	res = verify(edited)
+	assert.Equal([]Problem{{Line: 2, Reason: "entry 2 was modified"}}, res.Problems)
+
+	res = verify([]string{lines[0], lines[2], lines[3]})
+	assert.Equal(1, len(res.Problems))
+	assert.Equal(2, res.Problems[0].Line)
+	assert.Contains(res.Problems[0].Reason, "expected entry 2, found 3")
+
+	// Re-hashing an edited entry breaks the link to the next one
+	var e Entry
+	json.Unmarshal([]byte(edited[1]), &e)
+	e.Hash = entryHash(e.Seq, e.Prev, e.Record)
+	rehashed, _ := json.Marshal(e)
+	edited[1] = string(rehashed)
+	res = verify(edited)
+	assert.Equal([]Problem{{Line: 3, Reason: "chain broken: entry does not follow the previous one"}}, res.Problems)
+
+	res = verify([]string{lines[0], "garbage", lines[1]})
+	assert.Equal(2, res.Problems[0].Line)
+	assert.Equal(1, len(res.Problems))
+
+	// A tampered log is refused rather than extended into a valid looking chain
+	tail := append([]string(nil), lines...)
+	tail[3] = strings.Replace(tail[3], userId, "usr_other", 1)
+	ioutil.WriteFile(path, []byte(strings.Join(tail, "\n")+"\n"), 0600)
+	_, err = OpenLog(path)
+	assert.NotEqual(nil, err)
+	assert.Contains(err.Error(), "line 4: entry 4 was modified")
+	ioutil.WriteFile(path, []byte(strings.Join(edited, "\n")+"\n"), 0600)
+	_, err = OpenLog(path)
+	assert.Contains(err.Error(), "chain broken")
+
+	// A partially written entry is refused rather than appended to
+	ioutil.WriteFile(path, append(data, []byte(`{"seq":5`)...), 0600)
+	_, err = OpenLog(path)
+	assert.NotEqual(nil, err)
+	assert.True(bytes.HasPrefix(data, []byte(`{"seq":1,"prev":"`+Genesis)))
+}
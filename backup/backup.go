@@ -0,0 +1,117 @@
+// Package backup exports the structure of an account (users, groups, group
+// memberships and enrollment metadata) to a versioned snapshot and compares snapshots.
+// No biometric data is exported, since the API does not return it
+package backup
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apiutil"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+// SnapshotVersion is the format version written to new snapshots
+const SnapshotVersion = 1
+
+// Snapshot is the exported structure of an account
+type Snapshot struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Users     []User    `json:"users"`
+	Groups    []Group   `json:"groups"`
+}
+
+// User is a user with its group memberships and enrollment metadata
+type User struct {
+	UserId           string                    `json:"userId"`
+	CreatedAt        int                       `json:"createdAt"`
+	Groups           []string                  `json:"groups"`
+	VoiceEnrollments []structs.VoiceEnrollment `json:"voiceEnrollments"`
+	FaceEnrollments  []structs.FaceEnrollment  `json:"faceEnrollments"`
+	VideoEnrollments []structs.VideoEnrollment `json:"videoEnrollments"`
+}
+
+// Group is a group with its members
+type Group struct {
+	GroupId     string   `json:"groupId"`
+	CreatedAt   int      `json:"createdAt"`
+	Description string   `json:"description"`
+	Users       []string `json:"users"`
+}
+
+// Export walks all users and groups of the account the client belongs to
+func Export(vi voiceit2.VoiceIt2) (*Snapshot, error) {
+	snap := &Snapshot{Version: SnapshotVersion, CreatedAt: time.Now().UTC(), Users: []User{}, Groups: []Group{}}
+
+	ret, err := vi.GetAllUsers()
+	var gau structs.GetAllUsersReturn
+	if err := apiutil.Decode("GetAllUsers", ret, err, &gau); err != nil {
+		return nil, errors.New("Export error: " + err.Error())
+	}
+
+	for _, u := range gau.Users {
+		user, err := exportUser(vi, u)
+		if err != nil {
+			return nil, errors.New("Export error: " + err.Error())
+		}
+		snap.Users = append(snap.Users, user)
+	}
+
+	ret, err = vi.GetAllGroups()
+	var gag structs.GetAllGroupsReturn
+	if err := apiutil.Decode("GetAllGroups", ret, err, &gag); err != nil {
+		return nil, errors.New("Export error: " + err.Error())
+	}
+	for _, g := range gag.Groups {
+		users := append([]string{}, g.Users...)
+		sort.Strings(users)
+		snap.Groups = append(snap.Groups, Group{GroupId: g.GroupId, CreatedAt: g.CreatedAt, Description: g.Description, Users: users})
+	}
+
+	snap.sort()
+	return snap, nil
+}
+
+func exportUser(vi voiceit2.VoiceIt2, u structs.User) (User, error) {
+	user := User{UserId: u.UserId, CreatedAt: u.CreatedAt}
+
+	ret, err := vi.GetGroupsForUser(u.UserId)
+	var ggfu structs.GetGroupsForUserReturn
+	if err := apiutil.Decode("GetGroupsForUser", ret, err, &ggfu); err != nil {
+		return user, err
+	}
+	user.Groups = append([]string{}, ggfu.Groups...)
+	sort.Strings(user.Groups)
+
+	ret, err = vi.GetAllVoiceEnrollments(u.UserId)
+	var gave structs.GetAllVoiceEnrollmentsReturn
+	if err := apiutil.Decode("GetAllVoiceEnrollments", ret, err, &gave); err != nil {
+		return user, err
+	}
+	user.VoiceEnrollments = append([]structs.VoiceEnrollment{}, gave.VoiceEnrollments...)
+
+	ret, err = vi.GetAllFaceEnrollments(u.UserId)
+	var gafe structs.GetAllFaceEnrollmentsReturn
+	if err := apiutil.Decode("GetAllFaceEnrollments", ret, err, &gafe); err != nil {
+		return user, err
+	}
+	user.FaceEnrollments = append([]structs.FaceEnrollment{}, gafe.FaceEnrollments...)
+
+	ret, err = vi.GetAllVideoEnrollments(u.UserId)
+	var gavie structs.GetAllVideoEnrollmentsReturn
+	if err := apiutil.Decode("GetAllVideoEnrollments", ret, err, &gavie); err != nil {
+		return user, err
+	}
+	user.VideoEnrollments = append([]structs.VideoEnrollment{}, gavie.VideoEnrollments...)
+
+	return user, nil
+}
+
+// sort orders users and groups by id so snapshots of the same account are stable
+func (s *Snapshot) sort() {
+	sort.Slice(s.Users, func(i, j int) bool { return s.Users[i].UserId < s.Users[j].UserId })
+	sort.Slice(s.Groups, func(i, j int) bool { return s.Groups[i].GroupId < s.Groups[j].GroupId })
+}
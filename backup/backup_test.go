@@ -0,0 +1,63 @@
+package backup
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apitest"
+)
+
+func TestExportAndDiff(t *testing.T) {
+	assert := assert.New(t)
+	api, client := apitest.New(t)
+
+	alice := api.AddUser()
+	bob := api.AddUser()
+	staff := api.AddGroup("staff", alice)
+
+	before, err := Export(client)
+	assert.Equal(nil, err)
+	assert.Equal(2, len(before.Users))
+	assert.Equal(1, len(before.Groups))
+	assert.Equal("staff", before.Groups[0].Description)
+	assert.Equal([]string{alice}, before.Groups[0].Users)
+
+	for _, write := range []func(*Snapshot, *bytes.Buffer) error{
+		func(s *Snapshot, b *bytes.Buffer) error { return s.WriteJSON(b) },
+		func(s *Snapshot, b *bytes.Buffer) error { return s.WriteNDJSON(b) },
+	} {
+		buf := &bytes.Buffer{}
+		assert.Equal(nil, write(before, buf))
+		read, err := Read(buf)
+		assert.Equal(nil, err)
+		assert.Equal(before.CreatedAt.Unix(), read.CreatedAt.Unix())
+		assert.True(Diff(before, read).Empty())
+	}
+
+	client.AddUserToGroup(staff, bob)
+	client.DeleteUser(alice)
+	carol := api.AddUser()
+	client.CreateGroup("visitors")
+
+	after, err := Export(client)
+	assert.Equal(nil, err)
+	d := Diff(before, after)
+	assert.Equal([]string{carol}, d.AddedUsers)
+	assert.Equal([]string{alice}, d.RemovedUsers)
+	assert.Equal(1, len(d.ChangedUsers))
+	assert.Equal(bob, d.ChangedUsers[0].UserId)
+	assert.Equal([]string{staff}, d.ChangedUsers[0].GroupsAdded)
+	assert.Equal(1, len(d.AddedGroups))
+	assert.Equal([]GroupChange{{GroupId: staff, UsersAdded: []string{bob}, UsersRemoved: []string{alice}}}, d.ChangedGroups)
+
+	text := &bytes.Buffer{}
+	d.WriteText(text)
+	assert.Contains(text.String(), "- user "+alice)
+	assert.Contains(text.String(), "~ user "+bob+" joined "+staff)
+}
+
+func TestReadRejectsUnknownVersion(t *testing.T) {
+	_, err := Read(bytes.NewBufferString(`{"version": 99, "users": [], "groups": []}`))
+	assert.NotEqual(t, nil, err)
+}
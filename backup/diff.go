@@ -0,0 +1,188 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// EnrollmentCounts holds the number of enrollments of each kind for a user
+type EnrollmentCounts struct {
+	Voice int `json:"voice"`
+	Face  int `json:"face"`
+	Video int `json:"video"`
+}
+
+func (u User) counts() EnrollmentCounts {
+	return EnrollmentCounts{Voice: len(u.VoiceEnrollments), Face: len(u.FaceEnrollments), Video: len(u.VideoEnrollments)}
+}
+
+// UserChange describes how a user present in both snapshots differs
+type UserChange struct {
+	UserId        string           `json:"userId"`
+	GroupsAdded   []string         `json:"groupsAdded,omitempty"`
+	GroupsRemoved []string         `json:"groupsRemoved,omitempty"`
+	OldCounts     EnrollmentCounts `json:"oldCounts"`
+	NewCounts     EnrollmentCounts `json:"newCounts"`
+}
+
+// GroupChange describes how a group present in both snapshots differs
+type GroupChange struct {
+	GroupId        string   `json:"groupId"`
+	OldDescription string   `json:"oldDescription,omitempty"`
+	NewDescription string   `json:"newDescription,omitempty"`
+	UsersAdded     []string `json:"usersAdded,omitempty"`
+	UsersRemoved   []string `json:"usersRemoved,omitempty"`
+}
+
+// DiffReport lists everything that changed between two snapshots
+type DiffReport struct {
+	AddedUsers    []string      `json:"addedUsers"`
+	RemovedUsers  []string      `json:"removedUsers"`
+	ChangedUsers  []UserChange  `json:"changedUsers"`
+	AddedGroups   []string      `json:"addedGroups"`
+	RemovedGroups []string      `json:"removedGroups"`
+	ChangedGroups []GroupChange `json:"changedGroups"`
+}
+
+// Empty reports whether the snapshots were equivalent
+func (d DiffReport) Empty() bool {
+	return len(d.AddedUsers) == 0 && len(d.RemovedUsers) == 0 && len(d.ChangedUsers) == 0 &&
+		len(d.AddedGroups) == 0 && len(d.RemovedGroups) == 0 && len(d.ChangedGroups) == 0
+}
+
+// Diff compares an older snapshot with a newer one
+func Diff(before *Snapshot, after *Snapshot) DiffReport {
+	d := DiffReport{
+		AddedUsers: []string{}, RemovedUsers: []string{}, ChangedUsers: []UserChange{},
+		AddedGroups: []string{}, RemovedGroups: []string{}, ChangedGroups: []GroupChange{},
+	}
+
+	oldUsers := map[string]User{}
+	for _, u := range before.Users {
+		oldUsers[u.UserId] = u
+	}
+	newUsers := map[string]User{}
+	for _, u := range after.Users {
+		newUsers[u.UserId] = u
+		o, ok := oldUsers[u.UserId]
+		if !ok {
+			d.AddedUsers = append(d.AddedUsers, u.UserId)
+			continue
+		}
+		change := UserChange{
+			UserId:        u.UserId,
+			GroupsAdded:   subtract(u.Groups, o.Groups),
+			GroupsRemoved: subtract(o.Groups, u.Groups),
+			OldCounts:     o.counts(),
+			NewCounts:     u.counts(),
+		}
+		if len(change.GroupsAdded) > 0 || len(change.GroupsRemoved) > 0 || change.OldCounts != change.NewCounts {
+			d.ChangedUsers = append(d.ChangedUsers, change)
+		}
+	}
+	for _, u := range before.Users {
+		if _, ok := newUsers[u.UserId]; !ok {
+			d.RemovedUsers = append(d.RemovedUsers, u.UserId)
+		}
+	}
+
+	oldGroups := map[string]Group{}
+	for _, g := range before.Groups {
+		oldGroups[g.GroupId] = g
+	}
+	newGroups := map[string]Group{}
+	for _, g := range after.Groups {
+		newGroups[g.GroupId] = g
+		o, ok := oldGroups[g.GroupId]
+		if !ok {
+			d.AddedGroups = append(d.AddedGroups, g.GroupId)
+			continue
+		}
+		change := GroupChange{
+			GroupId:      g.GroupId,
+			UsersAdded:   subtract(g.Users, o.Users),
+			UsersRemoved: subtract(o.Users, g.Users),
+		}
+		if o.Description != g.Description {
+			change.OldDescription = o.Description
+			change.NewDescription = g.Description
+		}
+		if len(change.UsersAdded) > 0 || len(change.UsersRemoved) > 0 || o.Description != g.Description {
+			d.ChangedGroups = append(d.ChangedGroups, change)
+		}
+	}
+	for _, g := range before.Groups {
+		if _, ok := newGroups[g.GroupId]; !ok {
+			d.RemovedGroups = append(d.RemovedGroups, g.GroupId)
+		}
+	}
+
+	sort.Strings(d.AddedUsers)
+	sort.Strings(d.RemovedUsers)
+	sort.Strings(d.AddedGroups)
+	sort.Strings(d.RemovedGroups)
+	return d
+}
+
+// subtract returns the sorted elements of a that are not in b
+func subtract(a []string, b []string) []string {
+	in := map[string]bool{}
+	for _, s := range b {
+		in[s] = true
+	}
+	out := []string{}
+	for _, s := range a {
+		if !in[s] {
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// WriteText writes a human readable summary of the report, one change per line
+func (d DiffReport) WriteText(w io.Writer) error {
+	lines := []string{}
+	for _, id := range d.AddedUsers {
+		lines = append(lines, "+ user "+id)
+	}
+	for _, id := range d.RemovedUsers {
+		lines = append(lines, "- user "+id)
+	}
+	for _, c := range d.ChangedUsers {
+		for _, g := range c.GroupsAdded {
+			lines = append(lines, "~ user "+c.UserId+" joined "+g)
+		}
+		for _, g := range c.GroupsRemoved {
+			lines = append(lines, "~ user "+c.UserId+" left "+g)
+		}
+		if c.OldCounts != c.NewCounts {
+			lines = append(lines, fmt.Sprintf("~ user %s enrollments voice %d->%d face %d->%d video %d->%d",
+				c.UserId, c.OldCounts.Voice, c.NewCounts.Voice, c.OldCounts.Face, c.NewCounts.Face, c.OldCounts.Video, c.NewCounts.Video))
+		}
+	}
+	for _, id := range d.AddedGroups {
+		lines = append(lines, "+ group "+id)
+	}
+	for _, id := range d.RemovedGroups {
+		lines = append(lines, "- group "+id)
+	}
+	for _, c := range d.ChangedGroups {
+		if c.OldDescription != c.NewDescription {
+			lines = append(lines, fmt.Sprintf("~ group %s description %q -> %q", c.GroupId, c.OldDescription, c.NewDescription))
+		}
+		for _, u := range c.UsersAdded {
+			lines = append(lines, "~ group "+c.GroupId+" added "+u)
+		}
+		for _, u := range c.UsersRemoved {
+			lines = append(lines, "~ group "+c.GroupId+" removed "+u)
+		}
+	}
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package backup
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+// record is one line of an NDJSON snapshot. The first line is a header and
+// each following line holds either a user or a group
+type record struct {
+	Kind      string     `json:"kind"`
+	Version   int        `json:"version,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	User      *User      `json:"user,omitempty"`
+	Group     *Group     `json:"group,omitempty"`
+}
+
+// WriteJSON writes the snapshot as a single indented JSON document
+func (s *Snapshot) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return errors.New("WriteJSON error: " + err.Error())
+	}
+	return nil
+}
+
+// WriteNDJSON writes the snapshot with one record per line, which diffs well
+// with line based tools
+func (s *Snapshot) WriteNDJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(record{Kind: "header", Version: s.Version, CreatedAt: &s.CreatedAt}); err != nil {
+		return errors.New("WriteNDJSON error: " + err.Error())
+	}
+	for i := range s.Users {
+		if err := enc.Encode(record{Kind: "user", User: &s.Users[i]}); err != nil {
+			return errors.New("WriteNDJSON error: " + err.Error())
+		}
+	}
+	for i := range s.Groups {
+		if err := enc.Encode(record{Kind: "group", Group: &s.Groups[i]}); err != nil {
+			return errors.New("WriteNDJSON error: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// Read reads a snapshot written by either WriteJSON or WriteNDJSON
+func Read(r io.Reader) (*Snapshot, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var first json.RawMessage
+	if err := dec.Decode(&first); err != nil {
+		return nil, errors.New("Read error: " + err.Error())
+	}
+
+	var header record
+	if err := json.Unmarshal(first, &header); err != nil {
+		return nil, errors.New("Read error: " + err.Error())
+	}
+
+	snap := &Snapshot{Users: []User{}, Groups: []Group{}}
+	if header.Kind != "header" {
+		if err := json.Unmarshal(first, snap); err != nil {
+			return nil, errors.New("Read error: " + err.Error())
+		}
+	} else {
+		snap.Version = header.Version
+		if header.CreatedAt != nil {
+			snap.CreatedAt = *header.CreatedAt
+		}
+		for line := 2; ; line++ {
+			var rec record
+			err := dec.Decode(&rec)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, errors.New("Read error: line " + strconv.Itoa(line) + ": " + err.Error())
+			}
+			switch {
+			case rec.Kind == "user" && rec.User != nil:
+				snap.Users = append(snap.Users, *rec.User)
+			case rec.Kind == "group" && rec.Group != nil:
+				snap.Groups = append(snap.Groups, *rec.Group)
+			default:
+				return nil, errors.New("Read error: line " + strconv.Itoa(line) + ": unexpected record kind \"" + rec.Kind + "\"")
+			}
+		}
+	}
+
+	if snap.Version < 1 || snap.Version > SnapshotVersion {
+		return nil, errors.New("Read error: unsupported snapshot version " + strconv.Itoa(snap.Version))
+	}
+	snap.sort()
+	return snap, nil
+}
@@ -0,0 +1,128 @@
+package bulk
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apitest"
+)
+
+var wav = append([]byte("RIFF\x24\x00\x00\x00WAVE"), make([]byte, 8)...)
+
+func writeFile(t *testing.T, name string, data []byte) {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(name, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadManifests(t *testing.T) {
+	assert := assert.New(t)
+
+	rows, err := ReadCSV(strings.NewReader("external_id,modality,language,phrase,file\n" +
+		"alice,Voice,en-US,never forget tomorrow is a new day,alice/1.wav\n" +
+		"bob,face,,,bob.jpg\n"))
+	assert.Equal(nil, err)
+	assert.Equal([]Row{
+		{ExternalID: "alice", Modality: voiceit2.Voice, ContentLanguage: "en-US", Phrase: "never forget tomorrow is a new day", FilePath: "alice/1.wav"},
+		{ExternalID: "bob", Modality: voiceit2.Face, FilePath: "bob.jpg"},
+	}, rows)
+
+	_, err = ReadCSV(strings.NewReader("externalId,modality,filePath\nalice,voice,1.wav\n"))
+	assert.NotEqual(nil, err, "voice rows need a phrase")
+
+	rows, err = ReadJSONL(strings.NewReader(`{"externalId":"carol","modality":"video","contentLanguage":"en-US","phrase":"p","filePath":"c.mp4"}` + "\n\n"))
+	assert.Equal(nil, err)
+	assert.Equal(1, len(rows))
+	assert.Equal(voiceit2.Video, rows[0].Modality)
+
+	dir, _ := ioutil.TempDir("", "bulk")
+	defer os.RemoveAll(dir)
+	writeFile(t, filepath.Join(dir, "manifest.jsonl"), []byte(`{"externalId":"dave","modality":"face","filePath":"d.jpg"}`))
+	rows, err = ReadManifest(filepath.Join(dir, "manifest.jsonl"))
+	assert.Equal(nil, err)
+	assert.Equal(filepath.Join(dir, "d.jpg"), rows[0].FilePath)
+}
+
+func TestImporter(t *testing.T) {
+	assert := assert.New(t)
+	api, client := apitest.New(t)
+
+	dir, _ := ioutil.TempDir("", "bulk")
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"alice/voice/1.wav", "alice/voice/2.wav", "alice/voice/3.wav", "bob/voice/1.wav"} {
+		writeFile(t, filepath.Join(dir, "data", name), wav)
+	}
+	// An empty recording is rejected by the API and must be retried on resume
+	writeFile(t, filepath.Join(dir, "data", "bob/voice/2.wav"), []byte{})
+
+	rows, err := ScanDir(filepath.Join(dir, "data"), "en-US", "never forget tomorrow is a new day")
+	assert.Equal(nil, err)
+	assert.Equal(5, len(rows))
+
+	report := &bytes.Buffer{}
+	im := NewImporter(client)
+	im.Workers = 2
+	im.CheckpointPath = filepath.Join(dir, "checkpoint.json")
+	im.Report = report
+	results, err := im.Run(rows)
+	assert.Equal(nil, err)
+	succeeded := 0
+	for _, r := range results {
+		if r.Succeeded() {
+			succeeded++
+		}
+	}
+	assert.Equal(4, succeeded)
+	assert.Equal(2, len(api.UserIds()))
+	assert.Equal(3, api.EnrollmentCount(results[0].UserId, "voice"))
+	assert.Equal(6, len(strings.Split(strings.TrimSpace(report.String()), "\n")), "report has a header and a line per row")
+
+	// Resume after fixing the bad file: only the failed row is sent again
+	writeFile(t, filepath.Join(dir, "data", "bob/voice/2.wav"), wav)
+	calls := len(api.Calls())
+	resumed := NewImporter(client)
+	resumed.CheckpointPath = im.CheckpointPath
+	results, err = resumed.Run(rows)
+	assert.Equal(nil, err)
+	assert.Equal(1, len(api.Calls())-calls)
+	for _, r := range results {
+		assert.True(r.Succeeded(), r.FilePath)
+	}
+	assert.Equal(2, len(api.UserIds()))
+	assert.Equal(2, api.EnrollmentCount(results[3].UserId, "voice"))
+}
+
+func TestImporterStopsOnCheckpointFailure(t *testing.T) {
+	assert := assert.New(t)
+	api, client := apitest.New(t)
+
+	dir, _ := ioutil.TempDir("", "bulk")
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"alice/voice/1.wav", "bob/voice/1.wav", "carol/voice/1.wav", "dave/voice/1.wav"} {
+		writeFile(t, filepath.Join(dir, "data", name), wav)
+	}
+	rows, _ := ScanDir(filepath.Join(dir, "data"), "en-US", "never forget tomorrow is a new day")
+
+	report := &bytes.Buffer{}
+	im := NewImporter(client)
+	im.Workers = 1
+	im.CheckpointPath = filepath.Join(dir, "missing", "checkpoint.json")
+	im.Report = report
+	results, err := im.Run(rows)
+	assert.NotEqual(nil, err)
+	assert.Contains(err.Error(), "saving checkpoint")
+	assert.Equal(1, len(api.UserIds()), "no user is created after the failure")
+	for _, r := range results {
+		assert.Equal(ErrStopped, r.Err, r.ExternalID)
+	}
+	assert.Equal(5, len(strings.Split(strings.TrimSpace(report.String()), "\n")), "the partial report is written")
+}
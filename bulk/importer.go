@@ -0,0 +1,309 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apiutil"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/store"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+// ErrStopped is the error of rows that were not attempted because the import
+// stopped on a failure of the import as a whole
+var ErrStopped = errors.New("not attempted: the import stopped")
+
+// checkpointError is a failure to save the checkpoint, which stops the import
+type checkpointError struct {
+	err error
+}
+
+func (e *checkpointError) Error() string {
+	return "saving checkpoint: " + e.err.Error()
+}
+
+// Checkpoint records the progress of an import so an interrupted run can be resumed.
+// Users maps external IDs to the VoiceIt userIds created for them and Done holds
+// the rows that were enrolled successfully
+type Checkpoint struct {
+	Users map[string]string `json:"users"`
+	Done  map[string]bool   `json:"done"`
+}
+
+// Result is the outcome of one manifest row
+type Result struct {
+	Row
+	UserId       string
+	Status       int
+	ResponseCode string
+	Message      string
+	// Resumed is set for rows that were already enrolled by a previous run
+	Resumed bool
+	// Err holds transport, file or validation errors that kept the row from reaching the API
+	Err error
+}
+
+// Succeeded reports whether the row is enrolled
+func (r Result) Succeeded() bool {
+	return r.Resumed || (r.Err == nil && r.ResponseCode == "SUCC")
+}
+
+// Importer creates users and enrollments for manifest rows
+type Importer struct {
+	Client voiceit2.VoiceIt2
+	// Workers bounds the number of users enrolled concurrently. Defaults to 4
+	Workers int
+	// CheckpointPath is the file the progress is saved to after every row.
+	// If it already exists, rows it marks as done are skipped and its users are reused
+	CheckpointPath string
+	// Report, if set, receives a CSV line per row once the import finishes
+	Report io.Writer
+
+	mu         sync.Mutex
+	checkpoint Checkpoint
+}
+
+// NewImporter returns an Importer for the client with default settings
+func NewImporter(client voiceit2.VoiceIt2) *Importer {
+	return &Importer{Client: client, Workers: 4}
+}
+
+// Run enrolls all rows and returns their results in the same order.
+// Rows of one external ID are enrolled in order by a single worker after
+// its user is created. The returned error is only set for failures that stop
+// the import as a whole, such as an unwritable checkpoint; per-row failures
+// are reported in the results. After such a failure the workers stop taking
+// rows, the rows they did not reach fail with ErrStopped, and the report is
+// still written
+func (im *Importer) Run(rows []Row) ([]Result, error) {
+	if err := im.loadCheckpoint(); err != nil {
+		return nil, errors.New("Run error: " + err.Error())
+	}
+
+	// Group row indexes by external ID, keeping first-seen order
+	order := []string{}
+	byUser := map[string][]int{}
+	for i, row := range rows {
+		if _, ok := byUser[row.ExternalID]; !ok {
+			order = append(order, row.ExternalID)
+		}
+		byUser[row.ExternalID] = append(byUser[row.ExternalID], i)
+	}
+
+	workers := im.Workers
+	if workers < 1 {
+		workers = 4
+	}
+
+	// Every row is overwritten once a worker reaches it
+	results := make([]Result, len(rows))
+	for i, row := range rows {
+		results[i] = Result{Row: row, Err: ErrStopped}
+	}
+	jobs := make(chan string)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	var fatalOnce sync.Once
+	var fatal error
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for externalID := range jobs {
+				if err := im.importUser(rows, byUser[externalID], results, stop); err != nil {
+					fatalOnce.Do(func() {
+						fatal = err
+						close(stop)
+					})
+				}
+			}
+		}()
+	}
+dispatch:
+	for _, externalID := range order {
+		select {
+		case jobs <- externalID:
+		case <-stop:
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	var err error
+	if fatal != nil {
+		err = errors.New("Run error: " + fatal.Error())
+	}
+	if im.Report != nil {
+		if rerr := WriteReport(im.Report, results); rerr != nil && err == nil {
+			err = errors.New("Run error: " + rerr.Error())
+		}
+	}
+	return results, err
+}
+
+// importUser creates the user for one external ID if needed and enrolls its
+// rows, until stop is closed
+func (im *Importer) importUser(rows []Row, indexes []int, results []Result, stop <-chan struct{}) error {
+	if stopped(stop) {
+		return nil
+	}
+	externalID := rows[indexes[0]].ExternalID
+	userId, err := im.ensureUser(externalID)
+	if _, ok := err.(*checkpointError); ok {
+		return err
+	}
+	for _, i := range indexes {
+		if stopped(stop) {
+			return nil
+		}
+		row := rows[i]
+		results[i] = Result{Row: row, UserId: userId}
+		if im.isDone(row) {
+			results[i].Resumed = true
+			continue
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if verr := row.validate(); verr != nil {
+			results[i].Err = verr
+			continue
+		}
+		im.enroll(&results[i])
+		if results[i].Succeeded() {
+			if cerr := im.markDone(row); cerr != nil {
+				return &checkpointError{err: cerr}
+			}
+		}
+	}
+	return nil
+}
+
+func stopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (im *Importer) ensureUser(externalID string) (string, error) {
+	im.mu.Lock()
+	userId, ok := im.checkpoint.Users[externalID]
+	im.mu.Unlock()
+	if ok {
+		return userId, nil
+	}
+
+	ret, err := im.Client.CreateUser()
+	var cu structs.CreateUserReturn
+	if err := apiutil.Decode("CreateUser", ret, err, &cu); err != nil {
+		return "", err
+	}
+
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.checkpoint.Users[externalID] = cu.UserId
+	if err := im.saveCheckpoint(); err != nil {
+		return "", &checkpointError{err: err}
+	}
+	return cu.UserId, nil
+}
+
+// enrollmentReturn holds the fields shared by the create enrollment responses
+type enrollmentReturn struct {
+	Status       int    `json:"status"`
+	ResponseCode string `json:"responseCode"`
+	Message      string `json:"message"`
+}
+
+func (im *Importer) enroll(res *Result) {
+	_, ret, err := apiutil.Enroll(im.Client, res.Modality, res.UserId, res.ContentLanguage, res.Phrase, res.FilePath)
+	if err != nil {
+		res.Err = err
+		return
+	}
+	var er enrollmentReturn
+	if err := json.Unmarshal(ret, &er); err != nil {
+		res.Err = errors.New("invalid JSON response: " + err.Error())
+		return
+	}
+	res.Status = er.Status
+	res.ResponseCode = er.ResponseCode
+	res.Message = er.Message
+}
+
+func (im *Importer) isDone(row Row) bool {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	return im.checkpoint.Done[row.key()]
+}
+
+func (im *Importer) markDone(row Row) error {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.checkpoint.Done[row.key()] = true
+	return im.saveCheckpoint()
+}
+
+func (im *Importer) loadCheckpoint() error {
+	im.checkpoint = Checkpoint{Users: map[string]string{}, Done: map[string]bool{}}
+	if im.CheckpointPath == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(im.CheckpointPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &im.checkpoint); err != nil {
+		return errors.New("invalid checkpoint " + im.CheckpointPath + ": " + err.Error())
+	}
+	if im.checkpoint.Users == nil {
+		im.checkpoint.Users = map[string]string{}
+	}
+	if im.checkpoint.Done == nil {
+		im.checkpoint.Done = map[string]bool{}
+	}
+	return nil
+}
+
+// saveCheckpoint writes the checkpoint through a temporary file so a crash
+// never leaves a truncated checkpoint behind. The caller must hold im.mu
+func (im *Importer) saveCheckpoint() error {
+	if im.CheckpointPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(im.checkpoint)
+	if err != nil {
+		return err
+	}
+	return store.WriteFile(im.CheckpointPath, data, 0600)
+}
+
+// WriteReport writes the results as CSV with a header line
+func WriteReport(w io.Writer, results []Result) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"externalId", "modality", "filePath", "userId", "status", "responseCode", "message", "resumed", "error"})
+	for _, r := range results {
+		errText := ""
+		if r.Err != nil {
+			errText = r.Err.Error()
+		}
+		writer.Write([]string{r.ExternalID, string(r.Modality), r.FilePath, r.UserId, strconv.Itoa(r.Status), r.ResponseCode, r.Message, strconv.FormatBool(r.Resumed), errText})
+	}
+	writer.Flush()
+	return writer.Error()
+}
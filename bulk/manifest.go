@@ -0,0 +1,210 @@
+// Package bulk enrolls many users at once from archived recordings, described
+// either by a CSV/JSONL manifest or by a user/modality/file directory layout
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+)
+
+// Row is a single enrollment to perform. ExternalID is the caller's own
+// identifier for the person; all rows sharing it are enrolled to one user
+type Row struct {
+	ExternalID      string            `json:"externalId"`
+	Modality        voiceit2.Modality `json:"modality"`
+	ContentLanguage string            `json:"contentLanguage"`
+	Phrase          string            `json:"phrase"`
+	FilePath        string            `json:"filePath"`
+}
+
+// key identifies a row in the checkpoint
+func (r Row) key() string {
+	return r.ExternalID + "\x00" + string(r.Modality) + "\x00" + r.FilePath
+}
+
+func (r Row) validate() error {
+	if r.ExternalID == "" {
+		return errors.New("missing externalId")
+	}
+	if r.FilePath == "" {
+		return errors.New("missing filePath")
+	}
+	switch r.Modality {
+	case voiceit2.Voice, voiceit2.Video:
+		if r.Phrase == "" {
+			return errors.New("missing phrase for " + string(r.Modality) + " enrollment")
+		}
+	case voiceit2.Face:
+	default:
+		return errors.New("unknown modality \"" + string(r.Modality) + "\"")
+	}
+	return nil
+}
+
+// csvColumns maps accepted CSV header names to Row fields
+var csvColumns = map[string]string{
+	"externalid":       "externalId",
+	"external_id":      "externalId",
+	"modality":         "modality",
+	"contentlanguage":  "contentLanguage",
+	"content_language": "contentLanguage",
+	"language":         "contentLanguage",
+	"phrase":           "phrase",
+	"filepath":         "filePath",
+	"file_path":        "filePath",
+	"file":             "filePath",
+}
+
+// ReadCSV reads rows from a CSV manifest whose first line is a header naming
+// the columns externalId, modality, contentLanguage, phrase and filePath
+func ReadCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("ReadCSV error: " + err.Error())
+	}
+	columns := make([]string, len(header))
+	for i, name := range header {
+		columns[i] = csvColumns[strings.ToLower(strings.TrimSpace(name))]
+	}
+
+	rows := []Row{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("ReadCSV error: " + err.Error())
+		}
+		var row Row
+		for i, value := range record {
+			if i >= len(columns) {
+				break
+			}
+			switch columns[i] {
+			case "externalId":
+				row.ExternalID = value
+			case "modality":
+				row.Modality = voiceit2.Modality(strings.ToLower(value))
+			case "contentLanguage":
+				row.ContentLanguage = value
+			case "phrase":
+				row.Phrase = value
+			case "filePath":
+				row.FilePath = value
+			}
+		}
+		if err := row.validate(); err != nil {
+			return nil, errors.New("ReadCSV error: line " + strconv.Itoa(line) + ": " + err.Error())
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ReadJSONL reads rows from a manifest with one JSON object per line
+func ReadJSONL(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	rows := []Row{}
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var row Row
+		if err := json.Unmarshal([]byte(text), &row); err != nil {
+			return nil, errors.New("ReadJSONL error: line " + strconv.Itoa(line) + ": " + err.Error())
+		}
+		row.Modality = voiceit2.Modality(strings.ToLower(string(row.Modality)))
+		if err := row.validate(); err != nil {
+			return nil, errors.New("ReadJSONL error: line " + strconv.Itoa(line) + ": " + err.Error())
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("ReadJSONL error: " + err.Error())
+	}
+	return rows, nil
+}
+
+// ReadManifest reads a .csv or .jsonl manifest file. Relative file paths in
+// the manifest are resolved against the directory containing it
+func ReadManifest(manifestPath string) ([]Row, error) {
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		return nil, errors.New("ReadManifest error: " + err.Error())
+	}
+	defer f.Close()
+
+	var rows []Row
+	switch strings.ToLower(filepath.Ext(manifestPath)) {
+	case ".csv":
+		rows, err = ReadCSV(f)
+	case ".jsonl", ".ndjson":
+		rows, err = ReadJSONL(f)
+	default:
+		return nil, errors.New("ReadManifest error: unsupported manifest format " + filepath.Ext(manifestPath))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(manifestPath)
+	for i := range rows {
+		if !filepath.IsAbs(rows[i].FilePath) {
+			rows[i].FilePath = filepath.Join(dir, rows[i].FilePath)
+		}
+	}
+	return rows, nil
+}
+
+// ScanDir builds rows from a directory laid out as root/<externalId>/<modality>/<file>,
+// e.g. root/customer-42/voice/1.wav. contentLanguage and phrase apply to every
+// voice and video recording found
+func ScanDir(root string, contentLanguage string, phrase string) ([]Row, error) {
+	users, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, errors.New("ScanDir error: " + err.Error())
+	}
+	rows := []Row{}
+	for _, u := range users {
+		if !u.IsDir() || strings.HasPrefix(u.Name(), ".") {
+			continue
+		}
+		for _, modality := range []voiceit2.Modality{voiceit2.Voice, voiceit2.Face, voiceit2.Video} {
+			dir := filepath.Join(root, u.Name(), string(modality))
+			files, err := ioutil.ReadDir(dir)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, errors.New("ScanDir error: " + err.Error())
+			}
+			for _, f := range files {
+				if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+					continue
+				}
+				row := Row{ExternalID: u.Name(), Modality: modality, FilePath: filepath.Join(dir, f.Name())}
+				if modality != voiceit2.Face {
+					row.ContentLanguage = contentLanguage
+					row.Phrase = phrase
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/voiceittech/VoiceIt2-Go/v2/audit"
+)
+
+var auditCommand = &command{
+	name:    "audit verify",
+	args:    []string{"<log>"},
+	summary: "check the hash chain of an audit log for edited or missing entries",
+	run: func(e *env, o *options, a []string) ([]byte, error) {
+		res, err := audit.VerifyFile(a[0])
+		if err != nil {
+			return nil, err
+		}
+		// A broken chain gives the command a non-zero exit code
+		code := "SUCC"
+		if !res.OK() {
+			code = "TAMPERED"
+		}
+		return json.Marshal(struct {
+			ResponseCode string `json:"responseCode"`
+			*audit.Result
+		}{code, res})
+	},
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/voiceittech/VoiceIt2-Go/v2/backup"
+)
+
+var backupDiffCommand = &command{
+	name:    "backup diff",
+	args:    []string{"<before>", "<after>"},
+	summary: "compare two account snapshots; exits non-zero if they differ",
+	run: func(e *env, o *options, a []string) ([]byte, error) {
+		before, err := readSnapshot(a[0])
+		if err != nil {
+			return nil, err
+		}
+		after, err := readSnapshot(a[1])
+		if err != nil {
+			return nil, err
+		}
+		report := backup.Diff(before, after)
+		// Changes give the command a non-zero exit code, as with diff(1)
+		code := "SUCC"
+		if !report.Empty() {
+			code = "CHANGED"
+		}
+		return json.Marshal(struct {
+			ResponseCode string `json:"responseCode"`
+			backup.DiffReport
+		}{code, report})
+	},
+}
+
+// readSnapshot reads a snapshot file written by WriteJSON or WriteNDJSON
+func readSnapshot(path string) (*backup.Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return backup.Read(f)
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+// options holds the values of the flags commands may accept
+type options struct {
+	language    string
+	phrase      string
+	description string
+	ttl         time.Duration
+	firstName   string
+	lastName    string
+	email       string
+	password    string
+	apiKey      string
+	apiToken    string
+	baseUrl     string
+	livenessUrl string
+	targetFAR   float64
+	report      string
+}
+
+// command is a subcommand. Its flags are option names known to addFlag
+type command struct {
+	name    string
+	args    []string
+	flags   []string
+	summary string
+	// local commands do not call the API, so their output has no responseCode
+	local bool
+	run   func(e *env, o *options, args []string) ([]byte, error)
+}
+
+// addFlag registers the flag of a command option on fs
+func addFlag(fs *flag.FlagSet, o *options, name string) {
+	switch name {
+	case "language":
+		fs.StringVar(&o.language, name, "", "content language, for example en-US")
+	case "phrase":
+		fs.StringVar(&o.phrase, name, "", "phrase spoken in the recording")
+	case "description":
+		fs.StringVar(&o.description, name, "", "group description")
+	case "ttl":
+		fs.DurationVar(&o.ttl, name, 5*time.Minute, "token lifetime")
+	case "first-name":
+		fs.StringVar(&o.firstName, name, "", "sub-account first name")
+	case "last-name":
+		fs.StringVar(&o.lastName, name, "", "sub-account last name")
+	case "email":
+		fs.StringVar(&o.email, name, "", "sub-account email")
+	case "password":
+		fs.StringVar(&o.password, name, "", "sub-account password")
+	case "api-key":
+		fs.StringVar(&o.apiKey, name, "", "API key of the profile")
+	case "api-token":
+		fs.StringVar(&o.apiToken, name, "", "API token of the profile")
+	case "base-url":
+		fs.StringVar(&o.baseUrl, name, "", "API base URL of the profile")
+	case "liveness-url":
+		fs.StringVar(&o.livenessUrl, name, "", "liveness base URL of the profile, defaults to its base URL")
+	case "target-far":
+		fs.Float64Var(&o.targetFAR, name, 0.01, "false acceptance rate to find the threshold for")
+	case "report":
+		fs.StringVar(&o.report, name, "", "also write the report to this file, as CSV if it ends in .csv")
+	}
+}
+
+func (c *command) flagSet(o *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	for _, name := range c.flags {
+		addFlag(fs, o, name)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: voiceit2 %s [flags] %s\n%s\n", c.name, strings.Join(c.args, " "), c.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+var speech = []string{"language", "phrase"}
+
+var commands = []*command{
+	{name: "users list", summary: "list all users", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.GetAllUsers()
+	}},
+	{name: "users create", summary: "create a user", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.CreateUser()
+	}},
+	{name: "users get", args: []string{"<userId>"}, summary: "check that a user exists", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.CheckUserExists(a[0])
+	}},
+	{name: "users delete", args: []string{"<userId>"}, summary: "delete a user", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.DeleteUser(a[0])
+	}},
+	{name: "users groups", args: []string{"<userId>"}, summary: "list the groups of a user", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.GetGroupsForUser(a[0])
+	}},
+
+	{name: "groups list", summary: "list all groups", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.GetAllGroups()
+	}},
+	{name: "groups create", flags: []string{"description"}, summary: "create a group", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.CreateGroup(o.description)
+	}},
+	{name: "groups get", args: []string{"<groupId>"}, summary: "show a group and its users", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.GetGroup(a[0])
+	}},
+	{name: "groups exists", args: []string{"<groupId>"}, summary: "check that a group exists", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.CheckGroupExists(a[0])
+	}},
+	{name: "groups delete", args: []string{"<groupId>"}, summary: "delete a group", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.DeleteGroup(a[0])
+	}},
+	{name: "groups add", args: []string{"<groupId>", "<userId>"}, summary: "add a user to a group", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.AddUserToGroup(a[0], a[1])
+	}},
+	{name: "groups remove", args: []string{"<groupId>", "<userId>"}, summary: "remove a user from a group", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.RemoveUserFromGroup(a[0], a[1])
+	}},
+
+	{name: "enrollments list", args: []string{"<voice|face|video>", "<userId>"}, summary: "list the enrollments of a user", run: func(e *env, o *options, a []string) ([]byte, error) {
+		switch a[0] {
+		case "voice":
+			return e.client.GetAllVoiceEnrollments(a[1])
+		case "face":
+			return e.client.GetAllFaceEnrollments(a[1])
+		case "video":
+			return e.client.GetAllVideoEnrollments(a[1])
+		}
+		return nil, errModality(a[0])
+	}},
+	{name: "enrollments create", args: []string{"<voice|face|video>", "<userId>", "<file|url|->"}, flags: speech, summary: "enroll a user", run: func(e *env, o *options, a []string) ([]byte, error) {
+		if isURL(a[2]) {
+			switch a[0] {
+			case "voice":
+				return e.client.CreateVoiceEnrollmentByUrl(a[1], o.language, o.phrase, a[2])
+			case "face":
+				return e.client.CreateFaceEnrollmentByUrl(a[1], a[2])
+			case "video":
+				return e.client.CreateVideoEnrollmentByUrl(a[1], o.language, o.phrase, a[2])
+			}
+			return nil, errModality(a[0])
+		}
+		path, err := e.media(a[2])
+		if err != nil {
+			return nil, err
+		}
+		switch a[0] {
+		case "voice":
+			return e.client.CreateVoiceEnrollment(a[1], o.language, o.phrase, path)
+		case "face":
+			return e.client.CreateFaceEnrollment(a[1], path)
+		case "video":
+			return e.client.CreateVideoEnrollment(a[1], o.language, o.phrase, path)
+		}
+		return nil, errModality(a[0])
+	}},
+	{name: "enrollments delete-all", args: []string{"<userId>"}, summary: "delete all enrollments of a user", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.DeleteAllEnrollments(a[0])
+	}},
+
+	{name: "verify voice", args: []string{"<userId>", "<file|url|->"}, flags: speech, summary: "verify a user's voice", run: func(e *env, o *options, a []string) ([]byte, error) {
+		if isURL(a[1]) {
+			return e.client.VoiceVerificationByUrl(a[0], o.language, o.phrase, a[1])
+		}
+		path, err := e.media(a[1])
+		if err != nil {
+			return nil, err
+		}
+		return e.client.VoiceVerification(a[0], o.language, o.phrase, path)
+	}},
+	{name: "verify face", args: []string{"<userId>", "<file|url|->"}, summary: "verify a user's face", run: func(e *env, o *options, a []string) ([]byte, error) {
+		if isURL(a[1]) {
+			return e.client.FaceVerificationByUrl(a[0], a[1])
+		}
+		path, err := e.media(a[1])
+		if err != nil {
+			return nil, err
+		}
+		return e.client.FaceVerification(a[0], path)
+	}},
+	{name: "verify video", args: []string{"<userId>", "<file|url|->"}, flags: speech, summary: "verify a user's face and voice", run: func(e *env, o *options, a []string) ([]byte, error) {
+		if isURL(a[1]) {
+			return e.client.VideoVerificationByUrl(a[0], o.language, o.phrase, a[1])
+		}
+		path, err := e.media(a[1])
+		if err != nil {
+			return nil, err
+		}
+		return e.client.VideoVerification(a[0], o.language, o.phrase, path)
+	}},
+
+	{name: "identify voice", args: []string{"<groupId>", "<file|url|->"}, flags: speech, summary: "identify a voice in a group", run: func(e *env, o *options, a []string) ([]byte, error) {
+		if isURL(a[1]) {
+			return e.client.VoiceIdentificationByUrl(a[0], o.language, o.phrase, a[1])
+		}
+		path, err := e.media(a[1])
+		if err != nil {
+			return nil, err
+		}
+		return e.client.VoiceIdentification(a[0], o.language, o.phrase, path)
+	}},
+	{name: "identify face", args: []string{"<groupId>", "<file|url|->"}, summary: "identify a face in a group", run: func(e *env, o *options, a []string) ([]byte, error) {
+		if isURL(a[1]) {
+			return e.client.FaceIdentificationByUrl(a[0], a[1])
+		}
+		path, err := e.media(a[1])
+		if err != nil {
+			return nil, err
+		}
+		return e.client.FaceIdentification(a[0], path)
+	}},
+	{name: "identify video", args: []string{"<groupId>", "<file|url|->"}, flags: speech, summary: "identify a face and voice in a group", run: func(e *env, o *options, a []string) ([]byte, error) {
+		if isURL(a[1]) {
+			return e.client.VideoIdentificationByUrl(a[0], o.language, o.phrase, a[1])
+		}
+		path, err := e.media(a[1])
+		if err != nil {
+			return nil, err
+		}
+		return e.client.VideoIdentification(a[0], o.language, o.phrase, path)
+	}},
+
+	{name: "phrases list", args: []string{"<contentLanguage>"}, summary: "list the account's phrases", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.GetPhrases(a[0])
+	}},
+
+	{name: "tokens create", args: []string{"<userId>"}, flags: []string{"ttl"}, summary: "create a user token", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.CreateUserToken(a[0], o.ttl)
+	}},
+	{name: "tokens expire", args: []string{"<userId>"}, summary: "expire all tokens of a user", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.ExpireUserTokens(a[0])
+	}},
+
+	{name: "subaccounts create", args: []string{"<managed|unmanaged>"}, flags: subAccountFlags, summary: "create a sub-account", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return createSubAccount(e, o, a[0])
+	}},
+	{name: "subaccounts regenerate-token", args: []string{"<apiKey>"}, summary: "regenerate a sub-account's API token", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.RegenerateSubAccountAPIToken(a[0])
+	}},
+	{name: "subaccounts switch-type", args: []string{"<apiKey>"}, summary: "switch a sub-account between managed and unmanaged", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.SwitchSubAccountType(a[0])
+	}},
+	{name: "subaccounts delete", args: []string{"<apiKey>"}, summary: "delete a sub-account", run: func(e *env, o *options, a []string) ([]byte, error) {
+		return e.client.DeleteSubAccount(a[0])
+	}},
+}
+
+var commandsByName = func() map[string]*command {
+	m := map[string]*command{}
+	for _, c := range append(append(commands, profileCommands...), diagnoseCommand, evalCommand, auditCommand, backupDiffCommand) {
+		m[c.name] = c
+	}
+	return m
+}()
+
+var subAccountFlags = []string{"first-name", "last-name", "email", "password", "language"}
+
+func createSubAccount(e *env, o *options, subAccountType string) ([]byte, error) {
+	params := structs.CreateSubAccountRequest{FirstName: o.firstName, LastName: o.lastName, Email: o.email, Password: o.password, ContentLanguage: o.language}
+	switch subAccountType {
+	case "managed":
+		return e.client.CreateManagedSubAccount(params)
+	case "unmanaged":
+		return e.client.CreateUnmanagedSubAccount(params)
+	}
+	return nil, errors.New("sub-account type must be managed or unmanaged, not " + subAccountType)
+}
+
+func isURL(arg string) bool {
+	return strings.HasPrefix(arg, "https://") || strings.HasPrefix(arg, "http://")
+}
+
+func errModality(modality string) error {
+	return errors.New("modality must be voice, face or video, not " + modality)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/voiceittech/VoiceIt2-Go/v2/preflight"
+)
+
+// responseHints explains response codes of VoiceVerification to support staff
+var responseHints = map[string]string{
+	"FAIL":  "voice does not match the enrollments; re-enroll in the customer's usual environment",
+	"STTF":  "no speech recognized; check the recording and the content language",
+	"PDNM":  "the phrase spoken does not match; check -phrase and the enrollment phrases",
+	"PNTE":  "the phrase is not one of the account's phrases; see phrases list",
+	"NEHSD": "not enough enrollments; the user needs at least three voice enrollments",
+	"SSTQ":  "speech too quiet; ask the customer to speak louder or closer to the microphone",
+	"SSTL":  "speech too loud; lower the microphone gain",
+	"NFEF":  "file could not be processed; convert it to 16 kHz mono WAV",
+	"UNFD":  "user not found; check the userId",
+	"UNAC":  "credentials refused; check the profile",
+}
+
+// diagnosis is one row of the diagnose output
+type diagnosis struct {
+	File           string  `json:"file"`
+	Duration       string  `json:"duration"`
+	Level          string  `json:"level"`
+	ResponseCode   string  `json:"responseCode"`
+	Confidence     float64 `json:"confidence"`
+	Text           string  `json:"text"`
+	TextConfidence float64 `json:"textConfidence"`
+	TextMatch      bool    `json:"textMatch"`
+	Hints          string  `json:"hints"`
+}
+
+var diagnoseCommand = &command{
+	name:    "diagnose voice",
+	args:    []string{"<userId>", "<folder>"},
+	flags:   speech,
+	summary: "check and verify every recording in a folder and explain the results",
+	run: func(e *env, o *options, a []string) ([]byte, error) {
+		files, err := recordings(a[1])
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, errors.New("no recordings in " + a[1])
+		}
+
+		rows := make([]diagnosis, 0, len(files))
+		code := "SUCC"
+		for _, file := range files {
+			row := e.diagnose(a[0], o, file)
+			if row.ResponseCode != "SUCC" && code == "SUCC" {
+				code = row.ResponseCode
+			}
+			rows = append(rows, row)
+		}
+		// The summary responseCode gives the command its exit code
+		return json.Marshal(map[string]interface{}{"responseCode": code, "recordings": rows})
+	},
+}
+
+// recordings returns the media files of a folder in name order
+func recordings(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (e *env) diagnose(userId string, o *options, file string) diagnosis {
+	row := diagnosis{File: filepath.Base(file)}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		row.ResponseCode = "LOCAL"
+		row.Hints = err.Error()
+		return row
+	}
+	report := preflight.Analyze(data)
+	hints := report.Hints
+	if report.Analyzed {
+		row.Duration = report.Duration.String()
+		if !math.IsInf(report.Level, -1) {
+			row.Level = strconv.FormatFloat(report.Level, 'f', 1, 64) + " dBFS"
+		}
+	}
+
+	ret, err := e.client.VoiceVerification(userId, o.language, o.phrase, file)
+	if err != nil {
+		row.ResponseCode = "LOCAL"
+		row.Hints = strings.Join(append(hints, err.Error()), "; ")
+		return row
+	}
+	var vr struct {
+		ResponseCode   string  `json:"responseCode"`
+		Message        string  `json:"message"`
+		Confidence     float64 `json:"confidence"`
+		Text           string  `json:"text"`
+		TextConfidence float64 `json:"textConfidence"`
+	}
+	if err := json.Unmarshal(ret, &vr); err != nil {
+		row.ResponseCode = "LOCAL"
+		row.Hints = strings.Join(append(hints, "invalid reply: "+err.Error()), "; ")
+		return row
+	}
+	row.ResponseCode = vr.ResponseCode
+	row.Confidence = vr.Confidence
+	row.Text = vr.Text
+	row.TextConfidence = vr.TextConfidence
+	row.TextMatch = o.phrase == "" || strings.EqualFold(strings.TrimSpace(vr.Text), strings.TrimSpace(o.phrase))
+
+	if hint, ok := responseHints[vr.ResponseCode]; ok {
+		hints = append(hints, hint)
+	} else if vr.ResponseCode != "SUCC" {
+		hints = append(hints, vr.Message)
+	}
+	if vr.ResponseCode == "SUCC" && !row.TextMatch {
+		hints = append(hints, "verified, but the recording says \""+vr.Text+"\"")
+	}
+	row.Hints = strings.Join(hints, "; ")
+	return row
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/voiceittech/VoiceIt2-Go/v2/eval"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+// skippedTrial is a trial whose verification gave no usable confidence
+type skippedTrial struct {
+	UserId       string `json:"userId"`
+	File         string `json:"file"`
+	ResponseCode string `json:"responseCode"`
+	Message      string `json:"message"`
+}
+
+var evalCommand = &command{
+	name:    "eval run",
+	args:    []string{"<voice|face|video>", "<trials.csv>"},
+	flags:   []string{"language", "phrase", "target-far", "report"},
+	summary: "verify a labeled trial set and report FAR, FRR and the equal error rate",
+	local:   true,
+	run: func(e *env, o *options, a []string) ([]byte, error) {
+		f, err := os.Open(a[1])
+		if err != nil {
+			return nil, err
+		}
+		trials, err := eval.ReadTrials(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		// Files are relative to the trial set
+		dir := filepath.Dir(a[1])
+		var scored []eval.Trial
+		skipped := []skippedTrial{}
+		for _, t := range trials {
+			file := t.File
+			if !filepath.IsAbs(file) && !isURL(file) {
+				file = filepath.Join(dir, file)
+			}
+			confidence, s, err := e.verifyTrial(a[0], o, t.UserId, file)
+			if err != nil {
+				return nil, err
+			}
+			if s != nil {
+				s.File = t.File
+				skipped = append(skipped, *s)
+				continue
+			}
+			t.Confidence = confidence
+			scored = append(scored, t)
+		}
+
+		report, err := eval.Evaluate(scored, o.targetFAR)
+		if err != nil {
+			return nil, err
+		}
+		if o.report != "" {
+			if err := writeReport(report, o.report); err != nil {
+				return nil, err
+			}
+		}
+		return json.Marshal(struct {
+			*eval.Report
+			Skipped []skippedTrial `json:"skipped"`
+		}{report, skipped})
+	},
+}
+
+// verifyTrial verifies one trial and returns its confidence, or why it was skipped
+func (e *env) verifyTrial(modality string, o *options, userId, file string) (float64, *skippedTrial, error) {
+	var ret []byte
+	var err error
+	switch modality {
+	case "voice":
+		if isURL(file) {
+			ret, err = e.client.VoiceVerificationByUrl(userId, o.language, o.phrase, file)
+		} else {
+			ret, err = e.client.VoiceVerification(userId, o.language, o.phrase, file)
+		}
+	case "face":
+		if isURL(file) {
+			ret, err = e.client.FaceVerificationByUrl(userId, file)
+		} else {
+			ret, err = e.client.FaceVerification(userId, file)
+		}
+	case "video":
+		if isURL(file) {
+			ret, err = e.client.VideoVerificationByUrl(userId, o.language, o.phrase, file)
+		} else {
+			ret, err = e.client.VideoVerification(userId, o.language, o.phrase, file)
+		}
+	default:
+		return 0, nil, errModality(modality)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var reply struct {
+		ResponseCode string `json:"responseCode"`
+		Message      string `json:"message"`
+	}
+	json.Unmarshal(ret, &reply)
+	// Only a match or a mismatch says how close the sample was
+	if reply.ResponseCode != "SUCC" && reply.ResponseCode != "FAIL" {
+		return 0, &skippedTrial{UserId: userId, ResponseCode: reply.ResponseCode, Message: reply.Message}, nil
+	}
+	var t eval.Trial
+	switch modality {
+	case "voice":
+		var r structs.VoiceVerificationReturn
+		json.Unmarshal(ret, &r)
+		t = eval.VoiceTrial(false, r)
+	case "face":
+		var r structs.FaceVerificationReturn
+		json.Unmarshal(ret, &r)
+		t = eval.FaceTrial(false, r)
+	case "video":
+		var r structs.VideoVerificationReturn
+		json.Unmarshal(ret, &r)
+		t = eval.VideoTrial(false, r)
+	}
+	return t.Confidence, nil, nil
+}
+
+// writeReport saves the report as CSV points if path ends in .csv, as JSON otherwise
+func writeReport(report *eval.Report, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		err = report.WriteCSV(f)
+	} else {
+		err = report.WriteJSON(f)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.New("cannot write report: " + err.Error())
+	}
+	return nil
+}
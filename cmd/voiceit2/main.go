@@ -0,0 +1,254 @@
+// Command voiceit2 calls the VoiceIt API 2.0 from the command line.
+//
+// Usage:
+//
+//	voiceit2 [global flags] <command> <action> [flags] [arguments]
+//
+// Credentials are taken from the file given with -credentials, see
+// voiceit2.FileCredentials, or from the profile given with -profile. Otherwise
+// the VIAPIKEY and VIAPITOKEN environment variables are used if set, and the
+// default profile if not. Profiles are kept in ~/.config/voiceit2/config, see
+// voiceit2.Config, and managed with the profile commands.
+// Media arguments are file paths, URLs, or - to read the file from stdin.
+//
+// The exit code is 0 when the API answered SUCC, 3 when it rejected the request,
+// 4 when the credentials were refused, 5 when a user, group or sub-account was
+// not found, 2 for usage errors and 1 for other failures.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+)
+
+// Exit codes
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitRejected = 3
+	exitUnauth   = 4
+	exitNotFound = 5
+)
+
+// env is what a command runs with
+type env struct {
+	client voiceit2.VoiceIt2
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	output string
+	// config is the loaded profile config, saved to configPath by profile commands
+	config     *voiceit2.Config
+	configPath string
+	// temp holds files created from stdin, removed when the command ends
+	temp []string
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("voiceit2", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	credentialsFile := global.String("credentials", "", "read the API key and token from this JSON or INI `file`")
+	profileName := global.String("profile", "", "use the credentials and base URL of this `profile`")
+	configPath := global.String("config", defaultConfigPath(), "profile config `file`")
+	baseUrl := global.String("base-url", "https://api.voiceit.io", "API base `URL`")
+	livenessUrl := global.String("liveness-url", "", "liveness API base `URL`, defaults to the base URL when that is not the default")
+	output := global.String("output", "json", "output format: json or table")
+	timeout := global.Duration("timeout", time.Minute, "request timeout")
+	global.Usage = func() { usage(global) }
+	if err := global.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *output != "json" && *output != "table" {
+		fmt.Fprintln(stderr, "voiceit2: -output must be json or table")
+		return exitUsage
+	}
+
+	rest := global.Args()
+	if len(rest) < 2 {
+		global.Usage()
+		return exitUsage
+	}
+	cmd, ok := commandsByName[rest[0]+" "+rest[1]]
+	if !ok {
+		fmt.Fprintf(stderr, "voiceit2: unknown command %q\n", rest[0]+" "+rest[1])
+		global.Usage()
+		return exitUsage
+	}
+
+	config, err := voiceit2.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintln(stderr, "voiceit2: "+err.Error())
+		return exitError
+	}
+	var provider voiceit2.CredentialsProvider = voiceit2.NewEnvCredentials()
+	var profile voiceit2.Profile
+	switch {
+	case *credentialsFile != "":
+		provider = voiceit2.FileCredentials{Path: *credentialsFile}
+	case *profileName != "":
+		if profile, err = config.Profile(*profileName); err != nil {
+			fmt.Fprintln(stderr, "voiceit2: "+err.Error())
+			return exitUsage
+		}
+	case os.Getenv("VIAPIKEY") == "":
+		// Without a default profile, requests fail asking for VIAPIKEY
+		profile, _ = config.Profile("")
+	}
+	if profile.APIKey != "" {
+		provider = voiceit2.StaticCredentials{APIKey: profile.APIKey, APIToken: profile.APIToken}
+	}
+
+	client := voiceit2.NewClientWithProvider(provider)
+	client.BaseUrl = strings.TrimRight(*baseUrl, "/")
+	if !flagSet(global, "base-url") && profile.BaseUrl != "" {
+		client.BaseUrl = strings.TrimRight(profile.BaseUrl, "/")
+	}
+	// Liveness calls go to the same environment as the other calls
+	switch {
+	case *livenessUrl != "":
+		client.LivenessUrl = strings.TrimRight(*livenessUrl, "/")
+	case !flagSet(global, "base-url") && profile.LivenessUrl != "":
+		client.LivenessUrl = strings.TrimRight(profile.LivenessUrl, "/")
+	case client.BaseUrl != voiceit2.NewClient("", "").BaseUrl:
+		client.LivenessUrl = client.BaseUrl
+	}
+	client.HTTPClient = &http.Client{Timeout: *timeout}
+
+	e := &env{client: client, stdin: stdin, stdout: stdout, stderr: stderr, output: *output, config: config, configPath: *configPath}
+	defer e.cleanup()
+	return e.exec(cmd, rest[2:])
+}
+
+func (e *env) exec(cmd *command, args []string) int {
+	var o options
+	fs := cmd.flagSet(&o)
+	fs.SetOutput(e.stderr)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != len(cmd.args) {
+		fmt.Fprintf(e.stderr, "voiceit2: %s expects %d arguments\n", cmd.name, len(cmd.args))
+		fs.Usage()
+		return exitUsage
+	}
+
+	ret, err := cmd.run(e, &o, fs.Args())
+	if err != nil {
+		fmt.Fprintln(e.stderr, "voiceit2: "+err.Error())
+		return exitError
+	}
+	if err := e.print(ret); err != nil {
+		fmt.Fprintln(e.stderr, "voiceit2: "+err.Error())
+		return exitError
+	}
+	if cmd.local {
+		return exitOK
+	}
+	return exitCode(ret)
+}
+
+// flagSet reports whether a flag was given on the command line
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func defaultConfigPath() string {
+	path, err := voiceit2.DefaultConfigPath()
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// exitCode derives the exit code from the responseCode of a reply
+func exitCode(ret []byte) int {
+	var reply struct {
+		ResponseCode string `json:"responseCode"`
+	}
+	if err := json.Unmarshal(ret, &reply); err != nil {
+		return exitError
+	}
+	switch reply.ResponseCode {
+	case "SUCC":
+		return exitOK
+	case "UNAC":
+		return exitUnauth
+	case "UNFD", "GNFD", "ACNF":
+		return exitNotFound
+	case "LOCAL":
+		// Set by commands for failures on this side, such as unreadable files
+		return exitError
+	}
+	return exitRejected
+}
+
+// media returns a path for a media argument, saving stdin to a temporary file for "-"
+func (e *env) media(arg string) (string, error) {
+	if arg != "-" {
+		return arg, nil
+	}
+	data, err := ioutil.ReadAll(e.stdin)
+	if err != nil {
+		return "", errors.New("cannot read stdin: " + err.Error())
+	}
+	extension := ".bin"
+	if mt, ok := voiceit2.SniffMediaType(data); ok {
+		extension = mt.Extension
+	}
+	f, err := ioutil.TempFile("", "voiceit2-stdin-*"+extension)
+	if err != nil {
+		return "", err
+	}
+	e.temp = append(e.temp, f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+func (e *env) cleanup() {
+	for _, path := range e.temp {
+		os.Remove(path)
+	}
+}
+
+func usage(global *flag.FlagSet) {
+	out := global.Output()
+	fmt.Fprintln(out, "Usage: voiceit2 [global flags] <command> <action> [flags] [arguments]")
+	fmt.Fprintln(out, "\nGlobal flags:")
+	global.PrintDefaults()
+	fmt.Fprintln(out, "\nCommands:")
+	names := make([]string, 0, len(commandsByName))
+	for name := range commandsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd := commandsByName[name]
+		fmt.Fprintf(out, "  %-32s %s\n", name+" "+strings.Join(cmd.args, " "), cmd.summary)
+	}
+}
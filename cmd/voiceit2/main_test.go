@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/audit"
+	"github.com/voiceittech/VoiceIt2-Go/v2/backup"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apitest"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/fakeapi"
+)
+
+// runCLI runs the command line against api and returns the exit code and output
+func runCLI(api *fakeapi.Server, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"-base-url", api.URL}, args...), strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCLI(t *testing.T) {
+	assert := assert.New(t)
+	api := fakeapi.New("key", "tok")
+	defer api.Close()
+	home, _ := ioutil.TempDir("", "cli-config")
+	defer os.RemoveAll(home)
+	os.Setenv("XDG_CONFIG_HOME", home)
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	os.Setenv("VIAPIKEY", "key")
+	os.Setenv("VIAPITOKEN", "tok")
+	defer os.Unsetenv("VIAPIKEY")
+	defer os.Unsetenv("VIAPITOKEN")
+
+	code, out, _ := runCLI(api, "", "users", "create")
+	assert.Equal(exitOK, code)
+	var created struct {
+		UserId string `json:"userId"`
+	}
+	assert.Equal(nil, json.Unmarshal([]byte(out), &created))
+	assert.Contains(out, "\n  \"userId\"", "JSON is indented")
+
+	code, out, _ = runCLI(api, "", "-output", "table", "users", "list")
+	assert.Equal(exitOK, code)
+	assert.Contains(out, "CREATEDAT")
+	assert.Contains(out, created.UserId)
+
+	code, _, _ = runCLI(api, "", "users", "delete", "usr_missing")
+	assert.Equal(exitNotFound, code)
+
+	code, _, _ = runCLI(api, "ann\nnever forget tomorrow is a new day", "enrollments", "create", "-language", "en-US", "-phrase", "never forget tomorrow is a new day", "voice", created.UserId, "-")
+	assert.Equal(exitOK, code)
+	assert.Equal(1, api.EnrollmentCount(created.UserId, "voice"))
+
+	dir, _ := ioutil.TempDir("", "cli")
+	defer os.RemoveAll(dir)
+	other := filepath.Join(dir, "other.wav")
+	ioutil.WriteFile(other, []byte("bob\nnever forget tomorrow is a new day"), 0600)
+	code, out, _ = runCLI(api, "", "verify", "voice", "-language", "en-US", "-phrase", "never forget tomorrow is a new day", created.UserId, other)
+	assert.Equal(exitRejected, code)
+	assert.Contains(out, `"FAIL"`)
+
+	code, _, errOut := runCLI(api, "", "users", "delete")
+	assert.Equal(exitUsage, code)
+	assert.Contains(errOut, "expects 1 arguments")
+	code, _, _ = runCLI(api, "", "users", "rename", "x")
+	assert.Equal(exitUsage, code)
+
+	credentials := filepath.Join(dir, "credentials")
+	ioutil.WriteFile(credentials, []byte("[default]\napiKey = key\napiToken = wrong\n"), 0600)
+	code, _, _ = runCLI(api, "", "-credentials", credentials, "users", "list")
+	assert.Equal(exitUnauth, code)
+}
+
+func TestProfiles(t *testing.T) {
+	assert := assert.New(t)
+	api := fakeapi.New("key", "tok")
+	defer api.Close()
+	home, _ := ioutil.TempDir("", "cli-config")
+	defer os.RemoveAll(home)
+	os.Setenv("XDG_CONFIG_HOME", home)
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	code, _, _ := runCLI(api, "", "profile", "add", "-api-key", "key", "-api-token", "tok", "master")
+	assert.Equal(exitOK, code)
+	code, _, _ = runCLI(api, "", "profile", "use", "master")
+	assert.Equal(exitOK, code)
+
+	// The default profile is used without environment credentials
+	code, _, _ = runCLI(api, "", "users", "list")
+	assert.Equal(exitOK, code)
+
+	code, out, _ := runCLI(api, "", "profile", "add-from-subaccount", "-first-name", "Acme", "acme", "managed")
+	assert.Equal(exitOK, code)
+	var csa struct {
+		APIKey string `json:"apiKey"`
+	}
+	json.Unmarshal([]byte(out), &csa)
+
+	config, err := voiceit2.LoadConfig(filepath.Join(home, "voiceit2", "config"))
+	assert.Equal(nil, err)
+	assert.Equal("master", config.Default)
+	assert.Equal(csa.APIKey, config.Profiles["acme"].APIKey)
+	assert.Equal(api.SubAccountToken(csa.APIKey), config.Profiles["acme"].APIToken)
+	assert.Equal(api.URL, config.Profiles["acme"].BaseUrl)
+	assert.Equal("", config.Profiles["acme"].LivenessUrl, "liveness calls follow the base URL")
+
+	code, _, _ = runCLI(api, "", "-profile", "acme", "users", "create")
+	assert.Equal(exitOK, code)
+
+	code, out, _ = runCLI(api, "", "-output", "table", "profile", "list")
+	assert.Equal(exitOK, code)
+	assert.Contains(out, "acme")
+	assert.NotContains(out, api.SubAccountToken(csa.APIKey), "tokens are not listed")
+
+	code, _, _ = runCLI(api, "", "profile", "add", "-api-key", "key", "-base-url", "https://api.staging.example", "-liveness-url", "https://liveness.staging.example", "staging")
+	assert.Equal(exitOK, code)
+	config, _ = voiceit2.LoadConfig(filepath.Join(home, "voiceit2", "config"))
+	assert.Equal("https://liveness.staging.example", config.Profiles["staging"].LivenessUrl)
+
+	code, _, _ = runCLI(api, "", "-profile", "nobody", "users", "list")
+	assert.Equal(exitUsage, code)
+	code, _, _ = runCLI(api, "", "profile", "remove", "acme")
+	assert.Equal(exitOK, code)
+}
+
+func TestDiagnose(t *testing.T) {
+	assert := assert.New(t)
+	api := fakeapi.New("key", "tok")
+	defer api.Close()
+	home, _ := ioutil.TempDir("", "cli-diagnose")
+	defer os.RemoveAll(home)
+	os.Setenv("XDG_CONFIG_HOME", home)
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	os.Setenv("VIAPIKEY", "key")
+	os.Setenv("VIAPITOKEN", "tok")
+	defer os.Unsetenv("VIAPIKEY")
+	defer os.Unsetenv("VIAPITOKEN")
+
+	phrase := "never forget tomorrow is a new day"
+	userId := api.AddUser()
+	samples := filepath.Join(home, "samples")
+	os.Mkdir(samples, 0700)
+	enrollment := filepath.Join(home, "enrollment")
+	ioutil.WriteFile(enrollment, []byte("ann\n"+phrase), 0600)
+	runCLI(api, "", "enrollments", "create", "-language", "en-US", "-phrase", phrase, "voice", userId, enrollment)
+	ioutil.WriteFile(filepath.Join(samples, "1-good"), []byte("ann\n"+phrase), 0600)
+	ioutil.WriteFile(filepath.Join(samples, "2-other-speaker"), []byte("bob\n"+phrase), 0600)
+	ioutil.WriteFile(filepath.Join(samples, "3-wrong-phrase"), []byte("ann\ntoday is a nice day"), 0600)
+
+	code, out, _ := runCLI(api, "", "diagnose", "voice", "-language", "en-US", "-phrase", phrase, userId, samples)
+	assert.Equal(exitRejected, code)
+	var res struct {
+		Recordings []diagnosis `json:"recordings"`
+	}
+	assert.Equal(nil, json.Unmarshal([]byte(out), &res))
+	assert.Equal(3, len(res.Recordings))
+	assert.Equal("SUCC", res.Recordings[0].ResponseCode)
+	assert.True(res.Recordings[0].TextMatch)
+	assert.Contains(res.Recordings[0].Hints, "not a recognized media format", "pre-flight runs on every file")
+	assert.Equal("FAIL", res.Recordings[1].ResponseCode)
+	assert.Contains(res.Recordings[1].Hints, "re-enroll")
+	assert.False(res.Recordings[2].TextMatch)
+	assert.Contains(res.Recordings[2].Hints, "recording says")
+
+	code, out, _ = runCLI(api, "", "-output", "table", "diagnose", "voice", "-phrase", phrase, userId, samples)
+	assert.Contains(out, "TEXTMATCH")
+	assert.Contains(out, "2-other-speaker")
+
+	// A recording that cannot be read is a failure, not a rejection
+	broken := filepath.Join(home, "broken")
+	os.Mkdir(broken, 0700)
+	os.Symlink(filepath.Join(home, "missing"), filepath.Join(broken, "1-missing"))
+	code, out, _ = runCLI(api, "", "diagnose", "voice", "-phrase", phrase, userId, broken)
+	assert.Equal(exitError, code)
+	assert.Contains(out, `"responseCode": "LOCAL"`)
+}
+
+func TestEval(t *testing.T) {
+	assert := assert.New(t)
+	api := fakeapi.New("key", "tok")
+	defer api.Close()
+	home, _ := ioutil.TempDir("", "cli-eval")
+	defer os.RemoveAll(home)
+	os.Setenv("XDG_CONFIG_HOME", home)
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	os.Setenv("VIAPIKEY", "key")
+	os.Setenv("VIAPITOKEN", "tok")
+	defer os.Unsetenv("VIAPIKEY")
+	defer os.Unsetenv("VIAPITOKEN")
+
+	phrase := "never forget tomorrow is a new day"
+	userId := api.AddUser()
+	ioutil.WriteFile(filepath.Join(home, "ann"), []byte("ann\n"+phrase), 0600)
+	ioutil.WriteFile(filepath.Join(home, "bob"), []byte("bob\n"+phrase), 0600)
+	runCLI(api, "", "enrollments", "create", "-language", "en-US", "-phrase", phrase, "voice", userId, filepath.Join(home, "ann"))
+	trials := "userId,label,file\n" +
+		userId + ",genuine,ann\n" +
+		userId + ",impostor,bob\n" +
+		"usr_missing,genuine,ann\n"
+	ioutil.WriteFile(filepath.Join(home, "trials.csv"), []byte(trials), 0600)
+
+	report := filepath.Join(home, "report.csv")
+	code, out, _ := runCLI(api, "", "eval", "run", "-language", "en-US", "-phrase", phrase, "-report", report, "voice", filepath.Join(home, "trials.csv"))
+	assert.Equal(exitOK, code)
+	var res struct {
+		Genuine           int            `json:"genuine"`
+		Impostor          int            `json:"impostor"`
+		EER               float64        `json:"eer"`
+		ThresholdAtTarget float64        `json:"thresholdAtTarget"`
+		Skipped           []skippedTrial `json:"skipped"`
+	}
+	assert.Equal(nil, json.Unmarshal([]byte(out), &res))
+	assert.Equal(1, res.Genuine)
+	assert.Equal(1, res.Impostor)
+	assert.Equal(0.0, res.EER)
+	assert.True(res.ThresholdAtTarget > 40 && res.ThresholdAtTarget <= 95, res.ThresholdAtTarget)
+	assert.Equal(1, len(res.Skipped))
+	assert.Equal("UNFD", res.Skipped[0].ResponseCode)
+	data, _ := ioutil.ReadFile(report)
+	assert.True(strings.HasPrefix(string(data), "threshold,far,frr\n40,1,0\n"), string(data))
+
+	code, _, _ = runCLI(api, "", "eval", "run", "sound", filepath.Join(home, "trials.csv"))
+	assert.Equal(exitError, code)
+}
+
+func TestAuditVerify(t *testing.T) {
+	assert := assert.New(t)
+	api := fakeapi.New("key", "tok")
+	defer api.Close()
+	dir, _ := ioutil.TempDir("", "cli-audit")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+	log, _ := audit.OpenLog(path)
+	log.Audit(voiceit2.AuditRecord{Method: "VoiceVerification", UserId: "usr_1", ResponseCode: "SUCC"})
+	log.Audit(voiceit2.AuditRecord{Method: "VoiceVerification", UserId: "usr_1", ResponseCode: "FAIL"})
+	log.Close()
+
+	code, out, _ := runCLI(api, "", "audit", "verify", path)
+	assert.Equal(exitOK, code)
+	assert.Contains(out, `"entries": 2`)
+
+	data, _ := ioutil.ReadFile(path)
+	ioutil.WriteFile(path, bytes.Replace(data, []byte("FAIL"), []byte("SUCC"), 1), 0600)
+	code, out, _ = runCLI(api, "", "audit", "verify", path)
+	assert.Equal(exitRejected, code)
+	assert.Contains(out, "entry 2 was modified")
+}
+
+func TestBackupDiff(t *testing.T) {
+	assert := assert.New(t)
+	api, client := apitest.New(t)
+	dir, _ := ioutil.TempDir("", "cli-backup")
+	defer os.RemoveAll(dir)
+	save := func(name string) string {
+		snap, _ := backup.Export(client)
+		f, _ := os.Create(filepath.Join(dir, name))
+		defer f.Close()
+		snap.WriteNDJSON(f)
+		return f.Name()
+	}
+
+	alice := api.AddUser()
+	before := save("before.ndjson")
+	code, out, _ := runCLI(api, "", "backup", "diff", before, before)
+	assert.Equal(exitOK, code)
+	assert.Contains(out, `"addedUsers": []`)
+
+	bob := api.AddUser()
+	api.AddGroup("staff", alice)
+	after := save("after.ndjson")
+	code, out, _ = runCLI(api, "", "backup", "diff", before, after)
+	assert.Equal(exitRejected, code)
+	assert.Contains(out, `"responseCode": "CHANGED"`)
+	assert.Contains(out, bob)
+
+	code, _, _ = runCLI(api, "", "backup", "diff", before, filepath.Join(dir, "missing.ndjson"))
+	assert.Equal(exitError, code)
+}
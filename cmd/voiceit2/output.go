@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// print writes a reply as indented JSON or as a table
+func (e *env) print(ret []byte) error {
+	if e.output == "table" {
+		var reply map[string]interface{}
+		if err := json.Unmarshal(ret, &reply); err == nil {
+			return writeTable(e.stdout, reply)
+		}
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, ret, "", "  "); err != nil {
+		// Not JSON, print it as the API sent it
+		_, err := e.stdout.Write(ret)
+		return err
+	}
+	out.WriteByte('\n')
+	_, err := out.WriteTo(e.stdout)
+	return err
+}
+
+// writeTable prints the first list of objects in a reply as a table with a
+// column per field, or the reply's fields as name/value rows if it has no list
+func writeTable(w io.Writer, reply map[string]interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if rows, ok := firstList(reply); ok {
+		columns := columnsOf(rows)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+		for _, row := range rows {
+			cells := make([]string, len(columns))
+			for i, column := range columns {
+				cells[i] = cell(row[column])
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+		return tw.Flush()
+	}
+
+	names := make([]string, 0, len(reply))
+	for name := range reply {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t%s\n", name, cell(reply[name]))
+	}
+	return tw.Flush()
+}
+
+// firstList returns the rows of the reply's list of objects, taking the field
+// names in sorted order so the choice is stable
+func firstList(reply map[string]interface{}) ([]map[string]interface{}, bool) {
+	names := make([]string, 0, len(reply))
+	for name := range reply {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		list, ok := reply[name].([]interface{})
+		if !ok {
+			continue
+		}
+		rows := make([]map[string]interface{}, 0, len(list))
+		for _, item := range list {
+			row, ok := item.(map[string]interface{})
+			if !ok {
+				rows = nil
+				break
+			}
+			rows = append(rows, row)
+		}
+		if rows != nil {
+			return rows, true
+		}
+	}
+	return nil, false
+}
+
+// columnsOf returns the field names of all rows in sorted order
+func columnsOf(rows []map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var columns []string
+	for _, row := range rows {
+		for name := range row {
+			if !seen[name] {
+				seen[name] = true
+				columns = append(columns, name)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64, bool:
+		return fmt.Sprint(v)
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
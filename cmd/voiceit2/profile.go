@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+var profileCommands = []*command{
+	{name: "profile list", local: true, summary: "list the profiles without their tokens", run: func(e *env, o *options, a []string) ([]byte, error) {
+		type listed struct {
+			Name        string `json:"name"`
+			APIKey      string `json:"apiKey"`
+			BaseUrl     string `json:"baseUrl,omitempty"`
+			LivenessUrl string `json:"livenessUrl,omitempty"`
+			Default     bool   `json:"default"`
+		}
+		defaultName := e.config.Default
+		if defaultName == "" {
+			defaultName = "default"
+		}
+		profiles := []listed{}
+		for _, name := range sortedProfiles(e.config) {
+			p := e.config.Profiles[name]
+			profiles = append(profiles, listed{Name: name, APIKey: p.APIKey, BaseUrl: p.BaseUrl, LivenessUrl: p.LivenessUrl, Default: name == defaultName})
+		}
+		return json.Marshal(map[string]interface{}{"profiles": profiles})
+	}},
+	{name: "profile add", args: []string{"<name>"}, flags: []string{"api-key", "api-token", "base-url", "liveness-url"}, local: true, summary: "add or replace a profile", run: func(e *env, o *options, a []string) ([]byte, error) {
+		if o.apiKey == "" {
+			return nil, errors.New("-api-key is required")
+		}
+		return e.saveProfile(a[0], voiceit2.Profile{APIKey: o.apiKey, APIToken: o.apiToken, BaseUrl: o.baseUrl, LivenessUrl: o.livenessUrl})
+	}},
+	{name: "profile remove", args: []string{"<name>"}, local: true, summary: "remove a profile", run: func(e *env, o *options, a []string) ([]byte, error) {
+		if _, ok := e.config.Profiles[a[0]]; !ok {
+			return nil, errors.New("profile " + a[0] + " not found")
+		}
+		delete(e.config.Profiles, a[0])
+		if e.config.Default == a[0] {
+			e.config.Default = ""
+		}
+		if err := e.config.Save(e.configPath); err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]string{"removed": a[0]})
+	}},
+	{name: "profile use", args: []string{"<name>"}, local: true, summary: "make a profile the default", run: func(e *env, o *options, a []string) ([]byte, error) {
+		if _, ok := e.config.Profiles[a[0]]; !ok {
+			return nil, errors.New("profile " + a[0] + " not found")
+		}
+		e.config.Default = a[0]
+		if err := e.config.Save(e.configPath); err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]string{"default": a[0]})
+	}},
+	{name: "profile add-from-subaccount", args: []string{"<name>", "<managed|unmanaged>"}, flags: subAccountFlags, summary: "create a sub-account and save it as a profile", run: func(e *env, o *options, a []string) ([]byte, error) {
+		if _, ok := e.config.Profiles[a[0]]; ok {
+			return nil, errors.New("profile " + a[0] + " already exists")
+		}
+		ret, err := createSubAccount(e, o, a[1])
+		if err != nil {
+			return nil, err
+		}
+		var csa structs.CreateSubAccountReturn
+		if err := json.Unmarshal(ret, &csa); err != nil || csa.ResponseCode != "SUCC" {
+			// Print the API's answer and exit with its response code
+			return ret, nil
+		}
+		p := voiceit2.Profile{APIKey: csa.APIKey, APIToken: csa.APIToken}
+		defaults := voiceit2.NewClient("", "")
+		if e.client.BaseUrl != defaults.BaseUrl {
+			p.BaseUrl = e.client.BaseUrl
+		}
+		if e.client.LivenessUrl != defaults.LivenessUrl && e.client.LivenessUrl != p.BaseUrl {
+			p.LivenessUrl = e.client.LivenessUrl
+		}
+		if _, err := e.saveProfile(a[0], p); err != nil {
+			return nil, errors.New("sub-account " + csa.APIKey + " was created but not saved: " + err.Error())
+		}
+		return ret, nil
+	}},
+}
+
+func (e *env) saveProfile(name string, p voiceit2.Profile) ([]byte, error) {
+	if err := e.config.Set(name, p); err != nil {
+		return nil, err
+	}
+	if err := e.config.Save(e.configPath); err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]string{"saved": name, "apiKey": p.APIKey})
+}
+
+func sortedProfiles(c *voiceit2.Config) []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
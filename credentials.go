@@ -0,0 +1,265 @@
+package voiceit2
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoCredentials is returned by providers that have no credentials to offer
+var ErrNoCredentials = errors.New("no credentials found")
+
+// Credentials are the API key and token requests are authenticated with
+type Credentials struct {
+	APIKey   string `json:"apiKey"`
+	APIToken string `json:"apiToken"`
+}
+
+// CredentialsProvider supplies credentials. A client with a provider asks it for
+// credentials on every request, so implementations must be safe for concurrent use
+// and should be cheap to call
+type CredentialsProvider interface {
+	Credentials() (Credentials, error)
+}
+
+// NewClientWithProvider returns a new VoiceIt2 client that takes its API key and
+// token from provider on every request
+func NewClientWithProvider(provider CredentialsProvider) VoiceIt2 {
+	vi := NewClient("", "")
+	vi.CredentialsProvider = provider
+	return vi
+}
+
+// setAuth sets the Basic Auth credentials of req, asking the client's provider if it has one
+func (vi VoiceIt2) setAuth(req *http.Request) error {
+	if vi.CredentialsProvider == nil {
+		req.SetBasicAuth(vi.APIKey, vi.APIToken)
+		return nil
+	}
+	creds, err := vi.CredentialsProvider.Credentials()
+	if err != nil {
+		return errors.New("cannot get credentials: " + err.Error())
+	}
+	req.SetBasicAuth(creds.APIKey, creds.APIToken)
+	return nil
+}
+
+// StaticCredentials is a provider that always returns the same credentials
+type StaticCredentials Credentials
+
+func (s StaticCredentials) Credentials() (Credentials, error) {
+	if s.APIKey == "" {
+		return Credentials{}, ErrNoCredentials
+	}
+	return Credentials(s), nil
+}
+
+// EnvCredentials reads credentials from environment variables
+type EnvCredentials struct {
+	KeyVar   string
+	TokenVar string
+}
+
+// NewEnvCredentials returns a provider reading VIAPIKEY and VIAPITOKEN
+func NewEnvCredentials() EnvCredentials {
+	return EnvCredentials{KeyVar: "VIAPIKEY", TokenVar: "VIAPITOKEN"}
+}
+
+func (e EnvCredentials) Credentials() (Credentials, error) {
+	key := os.Getenv(e.KeyVar)
+	if key == "" {
+		return Credentials{}, errors.New(e.KeyVar + " is not set: " + ErrNoCredentials.Error())
+	}
+	return Credentials{APIKey: key, APIToken: os.Getenv(e.TokenVar)}, nil
+}
+
+// FileCredentials reads credentials from a file every time they are requested.
+// The file is either a JSON object with "apiKey" and "apiToken" fields or an INI
+// file with apiKey and apiToken entries (api_key and api_token are accepted too).
+// In an INI file the entries are taken from the section named Profile, or from
+// the "default" section or outside any section if Profile is empty
+type FileCredentials struct {
+	Path    string
+	Profile string
+}
+
+func (f FileCredentials) Credentials() (Credentials, error) {
+	data, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	return ParseCredentials(data, f.Profile)
+}
+
+// ParseCredentials parses the JSON or INI contents of a credentials file, see FileCredentials
+func ParseCredentials(data []byte, profile string) (Credentials, error) {
+	var creds Credentials
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &creds); err != nil {
+			return Credentials{}, errors.New("invalid JSON credentials: " + err.Error())
+		}
+	} else {
+		sections := parseINI(data)
+		var entries map[string]string
+		if profile != "" {
+			entries = sections[profile]
+			if entries == nil {
+				return Credentials{}, errors.New("profile " + profile + " not found: " + ErrNoCredentials.Error())
+			}
+		} else if entries = sections["default"]; entries == nil {
+			entries = sections[""]
+		}
+		creds = Credentials{APIKey: entries["apikey"], APIToken: entries["apitoken"]}
+	}
+	if creds.APIKey == "" {
+		return Credentials{}, ErrNoCredentials
+	}
+	return creds, nil
+}
+
+// parseINI returns the entries of each section of an INI file with their keys
+// lower-cased and stripped of underscores. Entries outside any section are under ""
+func parseINI(data []byte) map[string]map[string]string {
+	sections := map[string]map[string]string{}
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if sections[section] == nil {
+				sections[section] = map[string]string{}
+			}
+			continue
+		}
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.Replace(strings.TrimSpace(line[:eq]), "_", "", -1))
+		value := strings.Trim(strings.TrimSpace(line[eq+1:]), `"'`)
+		if sections[section] == nil {
+			sections[section] = map[string]string{}
+		}
+		sections[section][key] = value
+	}
+	return sections
+}
+
+// ChainCredentials returns the credentials of the first provider that has them
+type ChainCredentials []CredentialsProvider
+
+func (c ChainCredentials) Credentials() (Credentials, error) {
+	var failures []string
+	for _, provider := range c {
+		creds, err := provider.Credentials()
+		if err == nil {
+			return creds, nil
+		}
+		failures = append(failures, err.Error())
+	}
+	if len(failures) == 0 {
+		return Credentials{}, ErrNoCredentials
+	}
+	return Credentials{}, errors.New(ErrNoCredentials.Error() + ": " + strings.Join(failures, "; "))
+}
+
+// WatchedFileCredentials caches the credentials of a file and reloads them when
+// the file changes, so rotated secrets are picked up without a restart
+type WatchedFileCredentials struct {
+	file FileCredentials
+
+	mu    sync.RWMutex
+	creds Credentials
+	err   error
+	// data is the file content the credentials were parsed from. Files are
+	// compared by content because rewrites within the file system's timestamp
+	// granularity keep the modification time
+	data []byte
+
+	quit chan struct{}
+	once sync.Once
+}
+
+// WatchCredentialsFile loads the credentials in the file at path, see
+// FileCredentials, and checks the file for changes every interval until Close is
+// called. If a changed file cannot be read or parsed, the last good credentials are kept.
+// The interval must be positive
+func WatchCredentialsFile(path, profile string, interval time.Duration) (*WatchedFileCredentials, error) {
+	if interval <= 0 {
+		return nil, errors.New("WatchCredentialsFile error: interval must be positive")
+	}
+	w := &WatchedFileCredentials{file: FileCredentials{Path: path, Profile: profile}, quit: make(chan struct{})}
+	if err := w.reload(ioutil.ReadFile(path)); err != nil {
+		return nil, errors.New("WatchCredentialsFile error: " + err.Error())
+	}
+	go w.watch(interval)
+	return w, nil
+}
+
+func (w *WatchedFileCredentials) Credentials() (Credentials, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.creds, nil
+}
+
+// Err returns the error of the last reload, or nil if it succeeded
+func (w *WatchedFileCredentials) Err() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.err
+}
+
+// Close stops watching the file. The last credentials stay available
+func (w *WatchedFileCredentials) Close() error {
+	w.once.Do(func() { close(w.quit) })
+	return nil
+}
+
+func (w *WatchedFileCredentials) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.quit:
+			return
+		case <-ticker.C:
+		}
+		data, err := ioutil.ReadFile(w.file.Path)
+		w.mu.Lock()
+		unchanged := err == nil && bytes.Equal(data, w.data)
+		if unchanged {
+			// The file is back to the content of the current credentials
+			w.err = nil
+		}
+		w.mu.Unlock()
+		if !unchanged {
+			w.reload(data, err)
+		}
+	}
+}
+
+func (w *WatchedFileCredentials) reload(data []byte, err error) error {
+	var creds Credentials
+	if err == nil {
+		creds, err = ParseCredentials(data, w.file.Profile)
+	}
+	w.mu.Lock()
+	w.err = err
+	if err == nil {
+		w.creds = creds
+		w.data = data
+	}
+	w.mu.Unlock()
+	return err
+}
@@ -0,0 +1,112 @@
+package voiceit2
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/fakeapi"
+)
+
+func TestParseCredentials(t *testing.T) {
+	assert := assert.New(t)
+	creds, err := ParseCredentials([]byte(`{"apiKey": "key_json", "apiToken": "tok_json"}`), "")
+	assert.Equal(nil, err)
+	assert.Equal(Credentials{APIKey: "key_json", APIToken: "tok_json"}, creds)
+
+	ini := []byte("# VoiceIt\napi_key = key_top\n\n[default]\napiKey = key_default\napiToken = tok_default\n\n[staging]\napi_key = \"key_staging\"\napi_token = tok_staging\n")
+	creds, err = ParseCredentials(ini, "")
+	assert.Equal(nil, err)
+	assert.Equal(Credentials{APIKey: "key_default", APIToken: "tok_default"}, creds)
+	creds, err = ParseCredentials(ini, "staging")
+	assert.Equal(nil, err)
+	assert.Equal(Credentials{APIKey: "key_staging", APIToken: "tok_staging"}, creds)
+	_, err = ParseCredentials(ini, "prod")
+	assert.NotEqual(nil, err)
+
+	creds, err = ParseCredentials([]byte("apikey=key_top\napitoken=tok_top\n"), "")
+	assert.Equal(nil, err)
+	assert.Equal("key_top", creds.APIKey)
+}
+
+func TestChainCredentials(t *testing.T) {
+	assert := assert.New(t)
+	os.Setenv("VITESTKEY", "")
+	chain := ChainCredentials{
+		EnvCredentials{KeyVar: "VITESTKEY", TokenVar: "VITESTTOKEN"},
+		FileCredentials{Path: filepath.Join(os.TempDir(), "does-not-exist")},
+		StaticCredentials{APIKey: "key_static", APIToken: "tok_static"},
+	}
+	creds, err := chain.Credentials()
+	assert.Equal(nil, err)
+	assert.Equal("key_static", creds.APIKey)
+
+	os.Setenv("VITESTKEY", "key_env")
+	defer os.Unsetenv("VITESTKEY")
+	creds, _ = chain.Credentials()
+	assert.Equal("key_env", creds.APIKey)
+
+	_, err = ChainCredentials{}.Credentials()
+	assert.NotEqual(nil, err)
+}
+
+func TestWatchedFileCredentials(t *testing.T) {
+	assert := assert.New(t)
+	api := fakeapi.New("key", "tok")
+	defer api.Close()
+	dir, _ := ioutil.TempDir("", "credentials")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "credentials.json")
+	ioutil.WriteFile(path, []byte(`{"apiKey": "key", "apiToken": "stale"}`), 0600)
+
+	w, err := WatchCredentialsFile(path, "", 5*time.Millisecond)
+	assert.Equal(nil, err)
+	defer w.Close()
+	myVoiceIt := NewClientWithProvider(w)
+	myVoiceIt.BaseUrl = api.URL
+	ret, err := myVoiceIt.GetAllUsers()
+	assert.Equal(nil, err)
+	assert.Contains(string(ret), `"responseCode":"UNAC"`)
+
+	// Rewrite the file as a secrets manager would and wait for the reload
+	tmp := path + ".new"
+	ioutil.WriteFile(tmp, []byte("[default]\napiKey = key\napiToken = tok\n"), 0600)
+	os.Rename(tmp, path)
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if creds, _ := w.Credentials(); creds.APIToken == "tok" {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	ret, err = myVoiceIt.GetAllUsers()
+	assert.Equal(nil, err)
+	assert.Contains(string(ret), `"responseCode":"SUCC"`)
+
+	// A broken file keeps the last good credentials
+	ioutil.WriteFile(path, []byte("{not json"), 0600)
+	for w.Err() == nil && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.NotEqual(nil, w.Err())
+	creds, _ := w.Credentials()
+	assert.Equal("tok", creds.APIToken)
+
+	// Restoring the file clears the error even though the credentials are unchanged
+	ioutil.WriteFile(path, []byte("[default]\napiKey = key\napiToken = tok\n"), 0600)
+	deadline = time.Now().Add(2 * time.Second)
+	for w.Err() != nil && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(nil, w.Err())
+
+	_, err = WatchCredentialsFile(path, "", 0)
+	assert.NotEqual(nil, err, "a zero interval is refused instead of panicking")
+
+	myVoiceIt.CredentialsProvider = ChainCredentials{}
+	_, err = myVoiceIt.GetAllUsers()
+	assert.NotEqual(nil, err)
+}
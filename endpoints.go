@@ -0,0 +1,276 @@
+package voiceit2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+// Endpoint declares an API call. The client methods are calls of the endpoints
+// returned by EndpointByName; others can be declared to call with Do
+type Endpoint struct {
+	// Name identifies the call in errors and audit records
+	Name   string
+	Method string
+	// Path may contain {param} placeholders, replaced by the param unescaped
+	Path string
+	// Query lists the params sent in the query string
+	Query []string
+	// File is the multipart field the file at the filePath param is uploaded as
+	File string
+	// Fields lists the params sent as multipart form fields, in order
+	Fields []string
+	// Form sends a multipart body even when there are no fields or file
+	Form bool
+	// Language resolves the contentLanguage param with the client's defaults
+	Language bool
+	// Audit sends the outcome to the client's AuditSink
+	Audit bool
+	// Liveness sends the request to the client's LivenessUrl instead of BaseUrl
+	Liveness bool
+	// Response is the zero value of the structs type the reply decodes into
+	Response interface{}
+}
+
+// Params are the arguments of a call by name. Uploaded files are given by
+// their path in the filePath param
+type Params map[string]string
+
+var endpointTable = []Endpoint{
+	// Users
+	{Name: "GetAllUsers", Method: "GET", Path: "/users", Response: structs.GetAllUsersReturn{}},
+	{Name: "CreateUser", Method: "POST", Path: "/users", Response: structs.CreateUserReturn{}},
+	{Name: "CheckUserExists", Method: "GET", Path: "/users/{userId}", Response: structs.CheckUserExistsReturn{}},
+	{Name: "DeleteUser", Method: "DELETE", Path: "/users/{userId}", Response: structs.DeleteUserReturn{}},
+	{Name: "GetGroupsForUser", Method: "GET", Path: "/users/{userId}/groups", Response: structs.GetGroupsForUserReturn{}},
+
+	// Groups
+	{Name: "GetAllGroups", Method: "GET", Path: "/groups", Response: structs.GetAllGroupsReturn{}},
+	{Name: "GetGroup", Method: "GET", Path: "/groups/{groupId}", Response: structs.GetGroupReturn{}},
+	{Name: "CheckGroupExists", Method: "GET", Path: "/groups/{groupId}/exists", Response: structs.CheckGroupExistsReturn{}},
+	{Name: "CreateGroup", Method: "POST", Path: "/groups", Fields: []string{"description"}, Response: structs.CreateGroupReturn{}},
+	{Name: "AddUserToGroup", Method: "PUT", Path: "/groups/addUser", Fields: []string{"groupId", "userId"}, Response: structs.AddUserToGroupReturn{}},
+	{Name: "RemoveUserFromGroup", Method: "PUT", Path: "/groups/removeUser", Fields: []string{"groupId", "userId"}, Response: structs.RemoveUserFromGroupReturn{}},
+	{Name: "DeleteGroup", Method: "DELETE", Path: "/groups/{groupId}", Form: true, Response: structs.DeleteGroupReturn{}},
+
+	// Enrollments
+	{Name: "GetAllVoiceEnrollments", Method: "GET", Path: "/enrollments/voice/{userId}", Response: structs.GetAllVoiceEnrollmentsReturn{}},
+	{Name: "GetAllVideoEnrollments", Method: "GET", Path: "/enrollments/video/{userId}", Response: structs.GetAllVideoEnrollmentsReturn{}},
+	{Name: "GetAllFaceEnrollments", Method: "GET", Path: "/enrollments/face/{userId}", Response: structs.GetAllFaceEnrollmentsReturn{}},
+	{Name: "CreateVoiceEnrollment", Method: "POST", Path: "/enrollments/voice", File: "recording", Fields: []string{"userId", "contentLanguage", "phrase"}, Language: true, Response: structs.CreateVoiceEnrollmentReturn{}},
+	{Name: "CreateVoiceEnrollmentByUrl", Method: "POST", Path: "/enrollments/voice/byUrl", Fields: []string{"userId", "contentLanguage", "fileUrl", "phrase"}, Language: true, Response: structs.CreateVoiceEnrollmentByUrlReturn{}},
+	{Name: "CreateFaceEnrollment", Method: "POST", Path: "/enrollments/face", File: "video", Fields: []string{"userId"}, Response: structs.CreateFaceEnrollmentReturn{}},
+	{Name: "CreateFaceEnrollmentByUrl", Method: "POST", Path: "/enrollments/face/byUrl", Fields: []string{"userId", "fileUrl"}, Response: structs.CreateFaceEnrollmentByUrlReturn{}},
+	{Name: "CreateVideoEnrollment", Method: "POST", Path: "/enrollments/video", File: "video", Fields: []string{"userId", "contentLanguage", "phrase"}, Language: true, Response: structs.CreateVideoEnrollmentReturn{}},
+	{Name: "CreateVideoEnrollmentByUrl", Method: "POST", Path: "/enrollments/video/byUrl", Fields: []string{"userId", "contentLanguage", "fileUrl", "phrase"}, Language: true, Response: structs.CreateVideoEnrollmentByUrlReturn{}},
+	{Name: "DeleteAllEnrollments", Method: "DELETE", Path: "/enrollments/{userId}/all", Response: structs.DeleteAllEnrollmentsReturn{}},
+
+	// Verification
+	{Name: "VoiceVerification", Method: "POST", Path: "/verification/voice", File: "recording", Fields: []string{"userId", "contentLanguage", "phrase"}, Language: true, Audit: true, Response: structs.VoiceVerificationReturn{}},
+	{Name: "VoiceVerificationByUrl", Method: "POST", Path: "/verification/voice/byUrl", Fields: []string{"userId", "contentLanguage", "fileUrl", "phrase"}, Language: true, Audit: true, Response: structs.VoiceVerificationByUrlReturn{}},
+	{Name: "FaceVerification", Method: "POST", Path: "/verification/face", File: "video", Fields: []string{"userId"}, Audit: true, Response: structs.FaceVerificationReturn{}},
+	{Name: "FaceVerificationByUrl", Method: "POST", Path: "/verification/face/byUrl", Fields: []string{"fileUrl", "userId"}, Audit: true, Response: structs.FaceVerificationByUrlReturn{}},
+	{Name: "VideoVerification", Method: "POST", Path: "/verification/video", File: "video", Fields: []string{"userId", "contentLanguage", "phrase"}, Language: true, Audit: true, Response: structs.VideoVerificationReturn{}},
+	{Name: "VideoVerificationByUrl", Method: "POST", Path: "/verification/video/byUrl", Fields: []string{"userId", "contentLanguage", "fileUrl", "phrase"}, Language: true, Audit: true, Response: structs.VideoVerificationByUrlReturn{}},
+
+	// Identification
+	{Name: "VoiceIdentification", Method: "POST", Path: "/identification/voice", File: "recording", Fields: []string{"groupId", "contentLanguage", "phrase"}, Language: true, Audit: true, Response: structs.VoiceIdentificationReturn{}},
+	{Name: "VoiceIdentificationByUrl", Method: "POST", Path: "/identification/voice/byUrl", Fields: []string{"fileUrl", "groupId", "contentLanguage", "phrase"}, Language: true, Audit: true, Response: structs.VoiceIdentificationByUrlReturn{}},
+	{Name: "VideoIdentification", Method: "POST", Path: "/identification/video", File: "video", Fields: []string{"groupId", "contentLanguage", "phrase"}, Language: true, Audit: true, Response: structs.VideoIdentificationReturn{}},
+	{Name: "VideoIdentificationByUrl", Method: "POST", Path: "/identification/video/byUrl", Fields: []string{"fileUrl", "groupId", "contentLanguage", "phrase"}, Language: true, Audit: true, Response: structs.VideoIdentificationByUrlReturn{}},
+	{Name: "FaceIdentification", Method: "POST", Path: "/identification/face", File: "video", Fields: []string{"groupId"}, Audit: true, Response: structs.FaceIdentificationReturn{}},
+	{Name: "FaceIdentificationByUrl", Method: "POST", Path: "/identification/face/byUrl", Fields: []string{"fileUrl", "groupId"}, Audit: true, Response: structs.FaceIdentificationByUrlReturn{}},
+
+	// Liveness
+	{Name: "GetLivenessChallenge", Method: "GET", Path: "/v1/verification/{userId}/{contentLanguage}", Language: true, Liveness: true, Response: structs.LivenessChallengeReturn{}},
+	{Name: "FaceLivenessVerification", Method: "POST", Path: "/v1/verification/face", File: "file", Fields: []string{"userId", "lcoId"}, Audit: true, Liveness: true, Response: structs.LivenessVerificationReturn{}},
+	{Name: "VideoLivenessVerification", Method: "POST", Path: "/v1/verification/video", File: "file", Fields: []string{"userId", "contentLanguage", "phrase", "lcoId"}, Language: true, Audit: true, Liveness: true, Response: structs.LivenessVerificationReturn{}},
+	{Name: "VoiceLivenessVerification", Method: "POST", Path: "/v1/verification/audio", File: "file", Fields: []string{"userId", "contentLanguage", "lcoId"}, Language: true, Audit: true, Liveness: true, Response: structs.LivenessVerificationReturn{}},
+
+	// Phrases
+	{Name: "GetPhrases", Method: "GET", Path: "/phrases/{contentLanguage}", Language: true, Response: structs.GetPhrasesReturn{}},
+
+	// User tokens
+	{Name: "CreateUserToken", Method: "POST", Path: "/users/{userId}/token", Query: []string{"timeOut"}, Response: structs.CreateUserTokenReturn{}},
+	{Name: "ExpireUserTokens", Method: "POST", Path: "/users/{userId}/expireTokens", Response: structs.ExpireUserTokensReturn{}},
+
+	// Sub-accounts
+	{Name: "CreateManagedSubAccount", Method: "POST", Path: "/subaccount/managed", Fields: []string{"firstName", "lastName", "email", "password", "contentLanguage"}, Response: structs.CreateSubAccountReturn{}},
+	{Name: "CreateUnmanagedSubAccount", Method: "POST", Path: "/subaccount/unmanaged", Fields: []string{"firstName", "lastName", "email", "password", "contentLanguage"}, Response: structs.CreateSubAccountReturn{}},
+	{Name: "RegenerateSubAccountAPIToken", Method: "POST", Path: "/subaccount/{subAccountAPIKey}", Response: structs.RegenerateSubAccountAPITokenReturn{}},
+	{Name: "DeleteSubAccount", Method: "DELETE", Path: "/subaccount/{subAccountAPIKey}", Response: structs.DeleteSubAccountReturn{}},
+	{Name: "SwitchSubAccountType", Method: "POST", Path: "/subaccount/{subAccountAPIKey}/switchType", Response: structs.SwitchSubAccountTypeReturn{}},
+}
+
+var endpoints = func() map[string]Endpoint {
+	m := map[string]Endpoint{}
+	for _, e := range endpointTable {
+		m[e.Name] = e
+	}
+	return m
+}()
+
+// EndpointByName returns the endpoint of a client method, such as "VoiceVerification"
+func EndpointByName(name string) (Endpoint, bool) {
+	e, ok := endpoints[name]
+	return e, ok
+}
+
+// Response is the HTTP side of a reply
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	// Duration is the time from sending the request to reading the whole body
+	Duration time.Duration
+	Body     []byte
+}
+
+// call executes an endpoint and returns the reply
+func (vi VoiceIt2) call(e Endpoint, p Params) ([]byte, error) {
+	resp, err := vi.execute(context.Background(), e, p)
+	if resp == nil {
+		return []byte{}, err
+	}
+	return resp.Body, err
+}
+
+// execute sends the request of an endpoint and reads the reply. The response
+// is returned along with audit errors, as the call itself succeeded
+func (vi VoiceIt2) execute(ctx context.Context, e Endpoint, p Params) (*Response, error) {
+	if p == nil {
+		p = Params{}
+	}
+	if e.Language {
+		contentLanguage, err := vi.ContentLanguageFor(p["contentLanguage"])
+		if err != nil {
+			return nil, errors.New(e.Name + " error: " + err.Error())
+		}
+		p["contentLanguage"] = contentLanguage
+	}
+
+	req, media, err := vi.newEndpointRequest(e, p)
+	if err != nil {
+		return nil, errors.New(e.Name + " error: " + err.Error())
+	}
+	r, err := vi.roundTrip(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.New(e.Name + " error: " + err.Error())
+	}
+	reply := r.Body
+
+	if e.Audit {
+		record := AuditRecord{Method: e.Name, UserId: p["userId"], GroupId: p["groupId"], ContentLanguage: p["contentLanguage"], MediaUrl: p["fileUrl"]}
+		if err := vi.audit(record, media, reply); err != nil {
+			return r, errors.New(e.Name + " error: audit: " + err.Error())
+		}
+	}
+	return r, nil
+}
+
+// Do calls an endpoint and decodes the reply into T, usually the type of the
+// endpoint's Response. The reply is decoded whatever the HTTP status, as the
+// API describes failures in the body; the Response tells the status apart.
+// The Response is nil if no reply was received
+func Do[T any](ctx context.Context, vi VoiceIt2, e Endpoint, p Params) (T, *Response, error) {
+	var out T
+	resp, err := vi.execute(ctx, e, p)
+	if err != nil {
+		return out, resp, err
+	}
+	if err := json.Unmarshal(resp.Body, &out); err != nil {
+		return out, resp, errors.New(e.Name + " error: cannot decode reply: " + err.Error())
+	}
+	return out, resp, nil
+}
+
+// newEndpointRequest builds the request of an endpoint. It also returns the
+// contents of the uploaded file, if any
+func (vi VoiceIt2) newEndpointRequest(e Endpoint, p Params) (*http.Request, []byte, error) {
+	var body io.Reader
+	var media []byte
+	contentType := ""
+	if e.Form || e.File != "" || len(e.Fields) > 0 {
+		buf := &bytes.Buffer{}
+		writer := multipart.NewWriter(buf)
+		if e.File != "" {
+			var err error
+			if media, err = ioutil.ReadFile(p["filePath"]); err != nil {
+				return nil, nil, err
+			}
+			if err := vi.writeMediaPart(writer, e.File, p["filePath"], media); err != nil {
+				return nil, nil, err
+			}
+		}
+		for _, field := range e.Fields {
+			if err := writer.WriteField(field, p[field]); err != nil {
+				return nil, nil, err
+			}
+		}
+		writer.Close()
+		body, contentType = buf, writer.FormDataContentType()
+	}
+
+	req, err := http.NewRequest(e.Method, vi.endpointUrl(e, p), body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if contentType != "" {
+		req.Header.Add("Content-Type", contentType)
+	}
+	return req, media, nil
+}
+
+// endpointUrl returns the URL of an endpoint, with the notification URL if the client has one
+func (vi VoiceIt2) endpointUrl(e Endpoint, p Params) string {
+	pairs := make([]string, 0, 2*len(p))
+	for name, value := range p {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	base := vi.BaseUrl
+	if e.Liveness {
+		base = vi.LivenessUrl
+	}
+	u := base + strings.NewReplacer(pairs...).Replace(e.Path)
+	separator := "?"
+	for _, name := range e.Query {
+		u += separator + name + "=" + url.QueryEscape(p[name])
+		separator = "&"
+	}
+	return vi.withNotificationUrl(u)
+}
+
+// withNotificationUrl adds the client's notification URL, if any, to the query of u
+func (vi VoiceIt2) withNotificationUrl(u string) string {
+	if vi.NotificationUrl == "" {
+		return u
+	}
+	if strings.Contains(u, "?") {
+		return u + "&" + strings.TrimPrefix(vi.NotificationUrl, "?")
+	}
+	return u + vi.NotificationUrl
+}
+
+// roundTrip sends a request through send and reads the whole reply
+func (vi VoiceIt2) roundTrip(req *http.Request) (*Response, error) {
+	start := time.Now()
+	resp, err := vi.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	reply, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Duration: time.Since(start), Body: reply}, nil
+}
@@ -0,0 +1,92 @@
+package voiceit2
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+func TestEndpointTable(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(len(endpointTable), len(endpoints), "endpoint names are unique")
+	client := reflect.TypeOf(VoiceIt2{})
+	for _, e := range endpointTable {
+		_, ok := client.MethodByName(e.Name)
+		assert.True(ok, e.Name+" is a client method")
+		assert.True(strings.HasPrefix(e.Path, "/"), e.Name)
+		assert.NotNil(e.Response, e.Name)
+	}
+}
+
+func TestEndpointUrl(t *testing.T) {
+	assert := assert.New(t)
+	api, myVoiceIt := newFakeClient(t)
+	userId := api.AddUser()
+
+	var urls []string
+	myVoiceIt.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			urls = append(urls, req.URL.RequestURI())
+			return next(req)
+		}
+	})
+	myVoiceIt.CreateUserToken(userId, 90*time.Second)
+	myVoiceIt.AddNotificationUrl("https://example.com/hook")
+	myVoiceIt.CreateUserToken(userId, 90*time.Second)
+	myVoiceIt.SwitchSubAccountType("key_sub")
+	myVoiceIt.DeleteAllEnrollments(userId)
+
+	hook := "notificationURL=https%3A%2F%2Fexample.com%2Fhook"
+	assert.Equal([]string{
+		"/users/" + userId + "/token?timeOut=90",
+		"/users/" + userId + "/token?timeOut=90&" + hook,
+		"/subaccount/key_sub/switchType?" + hook,
+		"/enrollments/" + userId + "/all?" + hook,
+	}, urls)
+}
+
+func TestDo(t *testing.T) {
+	assert := assert.New(t)
+	api, myVoiceIt := newFakeClient(t)
+	userId := api.AddUser()
+	ctx := context.Background()
+
+	e, ok := EndpointByName("CheckUserExists")
+	assert.True(ok)
+	ret, resp, err := Do[structs.CheckUserExistsReturn](ctx, myVoiceIt, e, Params{"userId": userId})
+	assert.Equal(nil, err)
+	assert.True(ret.Exists)
+	assert.Equal("SUCC", ret.ResponseCode)
+	assert.Equal(200, resp.StatusCode)
+	assert.Contains(resp.Header.Get("Content-Type"), "json")
+	assert.True(resp.Duration > 0)
+	assert.Contains(string(resp.Body), `"exists":true`)
+
+	// Endpoints the client has no method for can be declared
+	groups := Endpoint{Name: "GetGroupsOfUser", Method: "GET", Path: "/users/{userId}/groups"}
+	var reply map[string]interface{}
+	reply, _, err = Do[map[string]interface{}](ctx, myVoiceIt, groups, Params{"userId": userId})
+	assert.Equal(nil, err)
+	assert.Equal("SUCC", reply["responseCode"])
+
+	deleteUser, _ := EndpointByName("DeleteUser")
+	deleted, resp, err := Do[structs.DeleteUserReturn](ctx, myVoiceIt, deleteUser, Params{"userId": "usr_missing"})
+	assert.Equal(nil, err, "API failures are replies")
+	assert.Equal("UNFD", deleted.ResponseCode)
+	assert.Equal(404, resp.StatusCode)
+
+	_, _, err = Do[[]string](ctx, myVoiceIt, e, Params{"userId": userId})
+	assert.Contains(err.Error(), "CheckUserExists error: cannot decode reply")
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, resp, err = Do[structs.CheckUserExistsReturn](cancelled, myVoiceIt, e, Params{"userId": userId})
+	assert.Contains(err.Error(), "context canceled")
+	assert.Nil(resp)
+}
@@ -0,0 +1,283 @@
+// Package erasure deletes everything VoiceIt holds about a person, as required by
+// right-to-erasure requests under GDPR and CCPA, and produces a signed deletion receipt
+package erasure
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apiutil"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/store"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+// Steps of an erasure, in the order they run
+const (
+	StepDeleteEnrollments = "deleteAllEnrollments"
+	StepRemoveFromGroups  = "removeFromGroups"
+	StepExpireTokens      = "expireUserTokens"
+	StepDeleteUser        = "deleteUser"
+	StepVerify            = "verifyUserGone"
+)
+
+var steps = []string{StepDeleteEnrollments, StepRemoveFromGroups, StepExpireTokens, StepDeleteUser, StepVerify}
+
+// Progress is the saved state of an erasure that has not finished yet
+type Progress struct {
+	UserId      string       `json:"userId"`
+	RequestedAt time.Time    `json:"requestedAt"`
+	Steps       []StepRecord `json:"steps"`
+	Groups      []string     `json:"groups"`
+}
+
+// deleted reports whether the DeleteUser step deleted the user
+func (p *Progress) deleted() bool {
+	for _, s := range p.Steps {
+		if s.Step == StepDeleteUser {
+			return !s.UserNotFound
+		}
+	}
+	return false
+}
+
+func (p *Progress) done(step string) bool {
+	for _, s := range p.Steps {
+		if s.Step == step {
+			return true
+		}
+	}
+	return false
+}
+
+// ProgressStore saves the progress of unfinished erasures so they can be resumed
+type ProgressStore interface {
+	Load(userId string) (p *Progress, ok bool, err error)
+	Save(p *Progress) error
+	Remove(userId string) error
+}
+
+// MemoryProgressStore is a ProgressStore kept in memory only
+type MemoryProgressStore struct {
+	m store.Map[Progress]
+}
+
+// NewMemoryProgressStore returns an empty MemoryProgressStore
+func NewMemoryProgressStore() *MemoryProgressStore {
+	return &MemoryProgressStore{}
+}
+
+func (s *MemoryProgressStore) Load(userId string) (*Progress, bool, error) {
+	p, ok := s.m.Get(userId)
+	if !ok {
+		return nil, false, nil
+	}
+	p.Steps = append([]StepRecord{}, p.Steps...)
+	p.Groups = append([]string{}, p.Groups...)
+	return &p, true, nil
+}
+
+func (s *MemoryProgressStore) Save(p *Progress) error {
+	cp := *p
+	cp.Steps = append([]StepRecord{}, p.Steps...)
+	cp.Groups = append([]string{}, p.Groups...)
+	s.m.Put(p.UserId, cp)
+	return nil
+}
+
+func (s *MemoryProgressStore) Remove(userId string) error {
+	s.m.Delete(userId)
+	return nil
+}
+
+// DirProgressStore keeps the progress of each erasure in its own JSON file in a directory
+type DirProgressStore struct {
+	Dir string
+}
+
+func (s DirProgressStore) path(userId string) string {
+	return filepath.Join(s.Dir, filepath.Base(userId)+".json")
+}
+
+func (s DirProgressStore) Load(userId string) (*Progress, bool, error) {
+	data, err := ioutil.ReadFile(s.path(userId))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var p Progress
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, false, err
+	}
+	return &p, true, nil
+}
+
+func (s DirProgressStore) Save(p *Progress) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return store.WriteFile(s.path(p.UserId), data, 0600)
+}
+
+func (s DirProgressStore) Remove(userId string) error {
+	err := os.Remove(s.path(userId))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Eraser runs erasures with the client
+type Eraser struct {
+	Client   voiceit2.VoiceIt2
+	Progress ProgressStore
+	Signer   Signer
+}
+
+// New returns an Eraser. The progress store lets an erasure that failed halfway
+// resume where it stopped; the signer signs the receipts
+func New(client voiceit2.VoiceIt2, progress ProgressStore, signer Signer) *Eraser {
+	return &Eraser{Client: client, Progress: progress, Signer: signer}
+}
+
+// Erase deletes the user's enrollments, removes the user from all groups,
+// expires the user's tokens, deletes the user and confirms with CheckUserExists
+// that the user is gone. Steps that already completed in an earlier call are
+// skipped, and a user that no longer exists counts as already deleted, so Erase
+// can be called again after any failure. The receipt is returned once every step
+// is done. Steps that found no such user are marked in it, and Deleted is only set
+// if DeleteUser actually deleted the user
+func (e *Eraser) Erase(userId string) (*Receipt, error) {
+	if userId == "" {
+		return nil, errors.New("Erase error: empty userId")
+	}
+	if e.Signer == nil {
+		return nil, errors.New("Erase error: no receipt signer configured")
+	}
+	p, ok, err := e.Progress.Load(userId)
+	if err != nil {
+		return nil, errors.New("Erase error: " + err.Error())
+	}
+	if !ok {
+		p = &Progress{UserId: userId, RequestedAt: time.Now().UTC(), Steps: []StepRecord{}, Groups: []string{}}
+		if err := e.Progress.Save(p); err != nil {
+			return nil, errors.New("Erase error: " + err.Error())
+		}
+	}
+
+	for _, step := range steps {
+		if p.done(step) {
+			continue
+		}
+		notFound, err := e.run(step, p)
+		if err != nil {
+			if serr := e.Progress.Save(p); serr != nil {
+				return nil, errors.New("Erase error: " + step + ": " + err.Error() + " (saving progress: " + serr.Error() + ")")
+			}
+			return nil, errors.New("Erase error: " + step + ": " + err.Error())
+		}
+		p.Steps = append(p.Steps, StepRecord{Step: step, CompletedAt: time.Now().UTC(), UserNotFound: notFound})
+		if err := e.Progress.Save(p); err != nil {
+			return nil, errors.New("Erase error: " + err.Error())
+		}
+	}
+
+	receipt := &Receipt{
+		ReceiptId:   newReceiptId(),
+		UserId:      userId,
+		RequestedAt: p.RequestedAt,
+		CompletedAt: time.Now().UTC(),
+		Steps:       p.Steps,
+		Groups:      p.Groups,
+		Deleted:     p.deleted(),
+		Verified:    true,
+	}
+	if err := receipt.sign(e.Signer); err != nil {
+		return nil, errors.New("Erase error: signing receipt: " + err.Error())
+	}
+	if err := e.Progress.Remove(userId); err != nil {
+		return nil, errors.New("Erase error: " + err.Error())
+	}
+	return receipt, nil
+}
+
+// run runs a step and reports whether the API did not know the user
+func (e *Eraser) run(step string, p *Progress) (bool, error) {
+	switch step {
+	case StepDeleteEnrollments:
+		ret, err := e.Client.DeleteAllEnrollments(p.UserId)
+		return notFound(apiutil.Decode("DeleteAllEnrollments", ret, err, nil), "UNFD")
+	case StepRemoveFromGroups:
+		ret, err := e.Client.GetGroupsForUser(p.UserId)
+		var ggfu structs.GetGroupsForUserReturn
+		if missing, err := notFound(apiutil.Decode("GetGroupsForUser", ret, err, &ggfu), "UNFD"); missing || err != nil {
+			return missing, err
+		}
+		for _, groupId := range ggfu.Groups {
+			ret, err := e.Client.RemoveUserFromGroup(groupId, p.UserId)
+			// A group deleted meanwhile no longer holds the user
+			missing, err := notFound(apiutil.Decode("RemoveUserFromGroup", ret, err, nil), "UNFD", "GNFD")
+			if err != nil {
+				return false, err
+			}
+			if !missing && !contains(p.Groups, groupId) {
+				p.Groups = append(p.Groups, groupId)
+			}
+		}
+		return false, nil
+	case StepExpireTokens:
+		ret, err := e.Client.ExpireUserTokens(p.UserId)
+		return notFound(apiutil.Decode("ExpireUserTokens", ret, err, nil), "UNFD")
+	case StepDeleteUser:
+		ret, err := e.Client.DeleteUser(p.UserId)
+		return notFound(apiutil.Decode("DeleteUser", ret, err, nil), "UNFD")
+	case StepVerify:
+		ret, err := e.Client.CheckUserExists(p.UserId)
+		var cue structs.CheckUserExistsReturn
+		if missing, err := notFound(apiutil.Decode("CheckUserExists", ret, err, &cue), "UNFD"); missing || err != nil {
+			return missing, err
+		}
+		if cue.Exists {
+			return false, errors.New("user " + p.UserId + " still exists")
+		}
+		return false, nil
+	}
+	return false, errors.New("unknown step " + step)
+}
+
+// notFound treats an API error with one of the given not found responseCodes as
+// a step that has nothing left to do, and reports whether that was the case
+func notFound(err error, codes ...string) (bool, error) {
+	if apiErr, ok := err.(*apiutil.APIError); ok {
+		for _, code := range codes {
+			if apiErr.ResponseCode == code {
+				return true, nil
+			}
+		}
+	}
+	return false, err
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func newReceiptId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "rcpt_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,97 @@
+package erasure
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apitest"
+)
+
+func TestErase(t *testing.T) {
+	assert := assert.New(t)
+	api, client := apitest.New(t)
+
+	userId := api.AddUser()
+	keep := api.AddUser()
+	staff := api.AddGroup("staff", userId, keep)
+	visitors := api.AddGroup("visitors", userId)
+
+	dir, _ := ioutil.TempDir("", "erasure")
+	defer os.RemoveAll(dir)
+	signer := HMACSigner{Key: []byte("receipt key")}
+	e := New(client, DirProgressStore{Dir: dir}, signer)
+
+	// Fail halfway through, then resume
+	api.Fail("POST /users/"+userId+"/expireTokens", 1, 503)
+	_, err := e.Erase(userId)
+	assert.NotEqual(nil, err)
+	calls := len(api.Calls())
+
+	receipt, err := e.Erase(userId)
+	assert.Equal(nil, err)
+	assert.Equal([]string{
+		"POST /users/" + userId + "/expireTokens",
+		"DELETE /users/" + userId,
+		"GET /users/" + userId,
+	}, api.Calls()[calls:], "completed steps are not repeated")
+
+	assert.Equal(userId, receipt.UserId)
+	assert.True(receipt.Deleted)
+	assert.True(receipt.Verified)
+	assert.Equal(5, len(receipt.Steps))
+	assert.ElementsMatch([]string{staff, visitors}, receipt.Groups)
+	assert.Equal([]string{keep}, api.UserIds())
+	assert.Equal(nil, VerifyReceipt(*receipt, signer))
+
+	tampered := *receipt
+	tampered.UserId = keep
+	assert.NotEqual(nil, VerifyReceipt(tampered, signer))
+	assert.NotEqual(nil, VerifyReceipt(*receipt, HMACSigner{Key: []byte("other key")}))
+
+	// Erasing a user that is already gone succeeds, but the receipt does not
+	// claim a deletion
+	receipt, err = e.Erase(userId)
+	assert.Equal(nil, err)
+	assert.Equal(0, len(receipt.Groups))
+	assert.False(receipt.Deleted)
+	for _, step := range receipt.Steps {
+		assert.Equal(step.Step != StepVerify, step.UserNotFound, step.Step)
+	}
+	receipt, err = e.Erase("usr_never")
+	assert.Equal(nil, err)
+	assert.False(receipt.Deleted)
+	assert.Equal(nil, VerifyReceipt(*receipt, signer))
+}
+
+func TestEraseGroupDeletedMeanwhile(t *testing.T) {
+	assert := assert.New(t)
+	api, client := apitest.New(t)
+	userId := api.AddUser()
+	staff := api.AddGroup("staff", userId)
+	visitors := api.AddGroup("visitors", userId)
+
+	// Another process deletes the visitors group while the user is removed from groups
+	deleted := false
+	racing := client
+	racing.Use(func(next voiceit2.Handler) voiceit2.Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			if strings.HasSuffix(req.URL.Path, "/groups/removeUser") && !deleted {
+				deleted = true
+				client.DeleteGroup(visitors)
+			}
+			return next(req)
+		}
+	})
+
+	e := New(racing, NewMemoryProgressStore(), HMACSigner{Key: []byte("receipt key")})
+	receipt, err := e.Erase(userId)
+	assert.Equal(nil, err)
+	assert.True(deleted)
+	assert.True(receipt.Deleted)
+	assert.Equal([]string{staff}, receipt.Groups)
+}
@@ -0,0 +1,115 @@
+package erasure
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// StepRecord is a completed step of an erasure
+type StepRecord struct {
+	Step        string    `json:"step"`
+	CompletedAt time.Time `json:"completedAt"`
+	// UserNotFound is set when the API did not know the user, so the step had nothing to do
+	UserNotFound bool `json:"userNotFound,omitempty"`
+}
+
+// Receipt is the signed record of a finished erasure, meant to be kept for compliance
+type Receipt struct {
+	ReceiptId   string       `json:"receiptId"`
+	UserId      string       `json:"userId"`
+	RequestedAt time.Time    `json:"requestedAt"`
+	CompletedAt time.Time    `json:"completedAt"`
+	Steps       []StepRecord `json:"steps"`
+	// Groups the user was removed from
+	Groups []string `json:"groups"`
+	// Deleted is set when DeleteUser deleted the user. It is false when the user
+	// did not exist, in which case the receipt only attests that VoiceIt holds no
+	// such user, not that a deletion took place
+	Deleted bool `json:"deleted"`
+	// Verified is set once CheckUserExists confirmed the user is gone
+	Verified  bool   `json:"verified"`
+	Algorithm string `json:"algorithm"`
+	Signature string `json:"signature"`
+}
+
+// Signer signs receipts. HMACSigner is provided; asymmetric signers can be
+// plugged in to let third parties check receipts without holding a secret
+type Signer interface {
+	Algorithm() string
+	Sign(payload []byte) ([]byte, error)
+}
+
+// Verifier checks receipt signatures
+type Verifier interface {
+	Verify(payload []byte, signature []byte) error
+}
+
+// HMACSigner signs and verifies receipts with HMAC-SHA256
+type HMACSigner struct {
+	Key []byte
+}
+
+func (s HMACSigner) Algorithm() string {
+	return "HMAC-SHA256"
+}
+
+func (s HMACSigner) Sign(payload []byte) ([]byte, error) {
+	if len(s.Key) == 0 {
+		return nil, errors.New("empty HMAC key")
+	}
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+func (s HMACSigner) Verify(payload []byte, signature []byte) error {
+	expected, err := s.Sign(payload)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(expected, signature) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// payload is the canonical encoding of the receipt that is signed: its JSON
+// encoding with the signature left empty
+func (r Receipt) payload() ([]byte, error) {
+	r.Signature = ""
+	return json.Marshal(r)
+}
+
+func (r *Receipt) sign(signer Signer) error {
+	r.Algorithm = signer.Algorithm()
+	payload, err := r.payload()
+	if err != nil {
+		return err
+	}
+	sig, err := signer.Sign(payload)
+	if err != nil {
+		return err
+	}
+	r.Signature = base64.StdEncoding.EncodeToString(sig)
+	return nil
+}
+
+// VerifyReceipt checks that the receipt was signed by the verifier's key and has not been modified
+func VerifyReceipt(r Receipt, verifier Verifier) error {
+	sig, err := base64.StdEncoding.DecodeString(r.Signature)
+	if err != nil {
+		return errors.New("VerifyReceipt error: " + err.Error())
+	}
+	payload, err := r.payload()
+	if err != nil {
+		return errors.New("VerifyReceipt error: " + err.Error())
+	}
+	if err := verifier.Verify(payload, sig); err != nil {
+		return errors.New("VerifyReceipt error: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+// Package eval measures how well verification confidences separate genuine
+// users from impostors, to choose acceptance thresholds from data
+package eval
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+// Trial is one verification whose ground truth is known
+type Trial struct {
+	// Genuine is true if the sample belongs to the verified user and false for an impostor
+	Genuine    bool    `json:"genuine"`
+	Confidence float64 `json:"confidence"`
+	// UserId and File identify the trial in reports and are optional
+	UserId string `json:"userId,omitempty"`
+	File   string `json:"file,omitempty"`
+}
+
+// VoiceTrial returns a trial for a VoiceVerification result
+func VoiceTrial(genuine bool, r structs.VoiceVerificationReturn) Trial {
+	return Trial{Genuine: genuine, Confidence: r.Confidence}
+}
+
+// FaceTrial returns a trial for a FaceVerification result
+func FaceTrial(genuine bool, r structs.FaceVerificationReturn) Trial {
+	return Trial{Genuine: genuine, Confidence: r.FaceConfidence}
+}
+
+// VideoTrial returns a trial for a VideoVerification result. A video is only
+// accepted if both confidences pass, so the lower one is used
+func VideoTrial(genuine bool, r structs.VideoVerificationReturn) Trial {
+	return Trial{Genuine: genuine, Confidence: math.Min(r.VoiceConfidence, r.FaceConfidence)}
+}
+
+// Point gives the error rates of accepting confidences at or above Threshold.
+// Plotting 1-FRR against FAR gives the ROC curve, FRR against FAR the DET curve
+type Point struct {
+	Threshold float64 `json:"threshold"`
+	// FAR is the share of impostor trials accepted
+	FAR float64 `json:"far"`
+	// FRR is the share of genuine trials rejected
+	FRR float64 `json:"frr"`
+}
+
+// Report summarizes a set of trials
+type Report struct {
+	Genuine  int     `json:"genuine"`
+	Impostor int     `json:"impostor"`
+	Points   []Point `json:"points"`
+	// EER is the equal error rate, where FAR and FRR cross, and EERThreshold the
+	// threshold it is reached at, both interpolated between points
+	EER          float64 `json:"eer"`
+	EERThreshold float64 `json:"eerThreshold"`
+	// TargetFAR is the FAR asked for. ThresholdAtTarget is the lowest threshold
+	// whose FAR does not exceed it, and FRRAtTarget the FRR at that threshold
+	TargetFAR         float64 `json:"targetFar"`
+	ThresholdAtTarget float64 `json:"thresholdAtTarget"`
+	FRRAtTarget       float64 `json:"frrAtTarget"`
+}
+
+// Evaluate computes the error rates of every threshold separating the trials.
+// It needs at least one genuine and one impostor trial
+func Evaluate(trials []Trial, targetFAR float64) (*Report, error) {
+	var genuine, impostor []float64
+	for _, t := range trials {
+		if t.Genuine {
+			genuine = append(genuine, t.Confidence)
+		} else {
+			impostor = append(impostor, t.Confidence)
+		}
+	}
+	if len(genuine) == 0 || len(impostor) == 0 {
+		return nil, errors.New("Evaluate error: need both genuine and impostor trials")
+	}
+	sort.Float64s(genuine)
+	sort.Float64s(impostor)
+
+	// Every distinct confidence is a threshold, plus one above all of them
+	seen := map[float64]bool{}
+	var thresholds []float64
+	for _, list := range [][]float64{genuine, impostor} {
+		for _, c := range list {
+			if !seen[c] {
+				seen[c] = true
+				thresholds = append(thresholds, c)
+			}
+		}
+	}
+	sort.Float64s(thresholds)
+	thresholds = append(thresholds, math.Nextafter(thresholds[len(thresholds)-1], math.Inf(1)))
+
+	r := &Report{Genuine: len(genuine), Impostor: len(impostor), TargetFAR: targetFAR}
+	for _, threshold := range thresholds {
+		rejected := sort.SearchFloat64s(genuine, threshold)
+		accepted := len(impostor) - sort.SearchFloat64s(impostor, threshold)
+		r.Points = append(r.Points, Point{
+			Threshold: threshold,
+			FAR:       float64(accepted) / float64(len(impostor)),
+			FRR:       float64(rejected) / float64(len(genuine)),
+		})
+	}
+
+	// FAR falls and FRR rises with the threshold, so they cross once
+	r.EER, r.EERThreshold = r.Points[0].FAR, r.Points[0].Threshold
+	for i := 1; i < len(r.Points); i++ {
+		a, b := r.Points[i-1], r.Points[i]
+		if b.FAR > b.FRR {
+			continue
+		}
+		da, db := a.FAR-a.FRR, b.FAR-b.FRR
+		f := 0.0
+		if da != db {
+			f = da / (da - db)
+		}
+		r.EER = a.FAR + f*(b.FAR-a.FAR)
+		r.EERThreshold = a.Threshold + f*(b.Threshold-a.Threshold)
+		break
+	}
+
+	for _, p := range r.Points {
+		if p.FAR <= targetFAR {
+			r.ThresholdAtTarget, r.FRRAtTarget = p.Threshold, p.FRR
+			break
+		}
+	}
+	return r, nil
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes the curve points with a threshold,far,frr header
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"threshold", "far", "frr"})
+	for _, p := range r.Points {
+		cw.Write([]string{formatFloat(p.Threshold), formatFloat(p.FAR), formatFloat(p.FRR)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadTrials reads trials from CSV with a header naming the columns userId,
+// label and file, and optionally confidence. Labels are genuine or impostor.
+// Trials without a confidence column are yet to be run
+func ReadTrials(r io.Reader) ([]Trial, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, errors.New("ReadTrials error: " + err.Error())
+	}
+	if len(records) == 0 {
+		return nil, errors.New("ReadTrials error: missing header")
+	}
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	for _, name := range []string{"userId", "label", "file"} {
+		if _, ok := columns[name]; !ok {
+			return nil, errors.New("ReadTrials error: missing " + name + " column")
+		}
+	}
+
+	trials := make([]Trial, 0, len(records)-1)
+	for n, record := range records[1:] {
+		line := strconv.Itoa(n + 2)
+		t := Trial{UserId: record[columns["userId"]], File: record[columns["file"]]}
+		switch record[columns["label"]] {
+		case "genuine":
+			t.Genuine = true
+		case "impostor":
+		default:
+			return nil, errors.New("ReadTrials error: line " + line + ": label must be genuine or impostor")
+		}
+		if i, ok := columns["confidence"]; ok && record[i] != "" {
+			if t.Confidence, err = strconv.ParseFloat(record[i], 64); err != nil {
+				return nil, errors.New("ReadTrials error: line " + line + ": " + err.Error())
+			}
+		}
+		trials = append(trials, t)
+	}
+	return trials, nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
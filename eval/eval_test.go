@@ -0,0 +1,71 @@
+package eval
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+func TestEvaluate(t *testing.T) {
+	assert := assert.New(t)
+	var trials []Trial
+	for _, c := range []float64{60, 80, 85, 90, 95} {
+		trials = append(trials, Trial{Genuine: true, Confidence: c})
+	}
+	for _, c := range []float64{20, 30, 40, 50, 70} {
+		trials = append(trials, Trial{Genuine: false, Confidence: c})
+	}
+
+	r, err := Evaluate(trials, 0)
+	assert.Equal(nil, err)
+	assert.Equal(5, r.Genuine)
+	assert.Equal(5, r.Impostor)
+	assert.Equal(Point{Threshold: 20, FAR: 1, FRR: 0}, r.Points[0])
+	last := r.Points[len(r.Points)-1]
+	assert.Equal(0.0, last.FAR)
+	assert.Equal(1.0, last.FRR)
+
+	// At 70 one impostor is accepted and one genuine user rejected
+	assert.InDelta(0.2, r.EER, 1e-9)
+	assert.InDelta(70, r.EERThreshold, 1e-9)
+	assert.Equal(80.0, r.ThresholdAtTarget)
+	assert.InDelta(0.2, r.FRRAtTarget, 1e-9)
+
+	r, _ = Evaluate(trials, 0.2)
+	assert.Equal(60.0, r.ThresholdAtTarget)
+	assert.Equal(0.0, r.FRRAtTarget)
+
+	_, err = Evaluate(trials[:5], 0.01)
+	assert.NotEqual(nil, err)
+
+	assert.Equal(10.0, VideoTrial(true, structs.VideoVerificationReturn{VoiceConfidence: 10, FaceConfidence: 90}).Confidence)
+	assert.Equal(90.0, FaceTrial(true, structs.FaceVerificationReturn{FaceConfidence: 90}).Confidence)
+}
+
+func TestReports(t *testing.T) {
+	assert := assert.New(t)
+	trials, err := ReadTrials(strings.NewReader("userId,label,file,confidence\nusr_1,genuine,a.wav,90\nusr_1,impostor,b.wav,30\nusr_2,genuine,c.wav,\n"))
+	assert.Equal(nil, err)
+	assert.Equal(3, len(trials))
+	assert.Equal(Trial{Genuine: true, Confidence: 90, UserId: "usr_1", File: "a.wav"}, trials[0])
+	assert.Equal(0.0, trials[2].Confidence)
+	_, err = ReadTrials(strings.NewReader("userId,label,file\nusr_1,maybe,a.wav\n"))
+	assert.NotEqual(nil, err)
+	_, err = ReadTrials(strings.NewReader("userId,file\n"))
+	assert.NotEqual(nil, err)
+
+	r, _ := Evaluate(trials[:2], 0.01)
+	var out bytes.Buffer
+	assert.Equal(nil, r.WriteCSV(&out))
+	assert.True(strings.HasPrefix(out.String(), "threshold,far,frr\n30,1,0\n90,0,0\n"), out.String())
+
+	out.Reset()
+	assert.Equal(nil, r.WriteJSON(&out))
+	var decoded Report
+	assert.Equal(nil, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(*r, decoded)
+}
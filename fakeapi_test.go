@@ -0,0 +1,19 @@
+package voiceit2
+
+import (
+	"testing"
+
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/fakeapi"
+)
+
+// newFakeClient starts a fake API, closed when the test ends, and returns it
+// with a client pointed at it. Package apitest does the same for the other
+// packages, which can import this one
+func newFakeClient(t *testing.T) (*fakeapi.Server, VoiceIt2) {
+	api := fakeapi.New("key", "tok")
+	t.Cleanup(api.Close)
+	client := NewClient("key", "tok")
+	client.BaseUrl = api.URL
+	client.LivenessUrl = api.URL
+	return api, client
+}
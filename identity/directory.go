@@ -0,0 +1,143 @@
+package identity
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apiutil"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+// ErrNotFound is returned when an external ID has no mapped user
+var ErrNotFound = errors.New("no user mapped to external ID")
+
+// Directory creates, resolves and deletes users by external ID
+type Directory struct {
+	Client voiceit2.VoiceIt2
+	Store  Store
+
+	// mu serializes EnsureUser so two callers cannot create two users for one external ID
+	mu sync.Mutex
+}
+
+// NewDirectory returns a Directory that keeps its mapping in store
+func NewDirectory(client voiceit2.VoiceIt2, store Store) *Directory {
+	return &Directory{Client: client, Store: store}
+}
+
+// EnsureUser returns the userId mapped to externalID, creating the user with
+// CreateUser and recording the mapping if there is none yet.
+// created reports whether a new user was created
+func (d *Directory) EnsureUser(externalID string) (userId string, created bool, err error) {
+	if externalID == "" {
+		return "", false, errors.New("EnsureUser error: empty external ID")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	userId, ok, err := d.Store.Get(externalID)
+	if err != nil {
+		return "", false, errors.New("EnsureUser error: " + err.Error())
+	}
+	if ok {
+		return userId, false, nil
+	}
+
+	ret, err := d.Client.CreateUser()
+	var cu structs.CreateUserReturn
+	if err := apiutil.Decode("CreateUser", ret, err, &cu); err != nil {
+		return "", false, errors.New("EnsureUser error: " + err.Error())
+	}
+	if err := d.Store.Put(externalID, cu.UserId); err != nil {
+		// Do not leave a user behind that nothing maps to
+		d.Client.DeleteUser(cu.UserId)
+		return "", false, errors.New("EnsureUser error: " + err.Error())
+	}
+	return cu.UserId, true, nil
+}
+
+// ResolveUser returns the userId mapped to externalID, or ErrNotFound
+func (d *Directory) ResolveUser(externalID string) (string, error) {
+	userId, ok, err := d.Store.Get(externalID)
+	if err != nil {
+		return "", errors.New("ResolveUser error: " + err.Error())
+	}
+	if !ok {
+		return "", ErrNotFound
+	}
+	return userId, nil
+}
+
+// DeleteByExternalID deletes the mapped user with DeleteUser and then removes
+// the mapping. A user that is already gone from VoiceIt only has its mapping removed
+func (d *Directory) DeleteByExternalID(externalID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	userId, ok, err := d.Store.Get(externalID)
+	if err != nil {
+		return errors.New("DeleteByExternalID error: " + err.Error())
+	}
+	if !ok {
+		return ErrNotFound
+	}
+
+	ret, err := d.Client.DeleteUser(userId)
+	if err := apiutil.Decode("DeleteUser", ret, err, nil); err != nil {
+		if apiErr, ok := err.(*apiutil.APIError); !ok || apiErr.ResponseCode != "UNFD" {
+			return errors.New("DeleteByExternalID error: " + err.Error())
+		}
+	}
+	if err := d.Store.Delete(externalID); err != nil {
+		return errors.New("DeleteByExternalID error: " + err.Error())
+	}
+	return nil
+}
+
+// Orphans lists inconsistencies between the mapping and the account
+type Orphans struct {
+	// Dangling maps external IDs to userIds that no longer exist in VoiceIt
+	Dangling map[string]string
+	// Unmapped lists userIds that exist in VoiceIt but no external ID maps to
+	Unmapped []string
+}
+
+// Empty reports whether the mapping and the account are consistent
+func (o Orphans) Empty() bool {
+	return len(o.Dangling) == 0 && len(o.Unmapped) == 0
+}
+
+// FindOrphans compares the mapping with the users returned by GetAllUsers
+func (d *Directory) FindOrphans() (Orphans, error) {
+	ret, err := d.Client.GetAllUsers()
+	var gau structs.GetAllUsersReturn
+	if err := apiutil.Decode("GetAllUsers", ret, err, &gau); err != nil {
+		return Orphans{}, errors.New("FindOrphans error: " + err.Error())
+	}
+	mapping, err := d.Store.List()
+	if err != nil {
+		return Orphans{}, errors.New("FindOrphans error: " + err.Error())
+	}
+
+	exists := map[string]bool{}
+	for _, u := range gau.Users {
+		exists[u.UserId] = true
+	}
+	mapped := map[string]bool{}
+	orphans := Orphans{Dangling: map[string]string{}, Unmapped: []string{}}
+	for externalID, userId := range mapping {
+		mapped[userId] = true
+		if !exists[userId] {
+			orphans.Dangling[externalID] = userId
+		}
+	}
+	for _, u := range gau.Users {
+		if !mapped[u.UserId] {
+			orphans.Unmapped = append(orphans.Unmapped, u.UserId)
+		}
+	}
+	sort.Strings(orphans.Unmapped)
+	return orphans, nil
+}
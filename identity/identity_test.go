@@ -0,0 +1,64 @@
+package identity
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apitest"
+)
+
+func TestFileStore(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "identity")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ids.json")
+
+	s, err := OpenFileStore(path)
+	assert.Equal(nil, err)
+	assert.Equal(nil, s.Put("cust-1", "usr_1"))
+	assert.Equal(nil, s.Put("cust-2", "usr_2"))
+	assert.Equal(nil, s.Delete("cust-1"))
+	assert.Equal(nil, s.Delete("cust-missing"))
+
+	reopened, err := OpenFileStore(path)
+	assert.Equal(nil, err)
+	all, _ := reopened.List()
+	assert.Equal(map[string]string{"cust-2": "usr_2"}, all)
+}
+
+func TestDirectory(t *testing.T) {
+	assert := assert.New(t)
+	api, client := apitest.New(t)
+	d := NewDirectory(client, NewMemoryStore())
+
+	userId, created, err := d.EnsureUser("cust-1")
+	assert.Equal(nil, err)
+	assert.True(created)
+	again, created, err := d.EnsureUser("cust-1")
+	assert.Equal(nil, err)
+	assert.False(created)
+	assert.Equal(userId, again)
+
+	resolved, err := d.ResolveUser("cust-1")
+	assert.Equal(nil, err)
+	assert.Equal(userId, resolved)
+	_, err = d.ResolveUser("cust-2")
+	assert.Equal(ErrNotFound, err)
+
+	// A user deleted behind the directory's back and a user created outside it
+	other, _, _ := d.EnsureUser("cust-2")
+	client.DeleteUser(other)
+	stray := api.AddUser()
+	orphans, err := d.FindOrphans()
+	assert.Equal(nil, err)
+	assert.Equal(map[string]string{"cust-2": other}, orphans.Dangling)
+	assert.Equal([]string{stray}, orphans.Unmapped)
+
+	assert.Equal(nil, d.DeleteByExternalID("cust-1"))
+	assert.Equal(nil, d.DeleteByExternalID("cust-2"), "deleting a user that is already gone only removes the mapping")
+	assert.Equal(ErrNotFound, d.DeleteByExternalID("cust-2"))
+	assert.Equal([]string{stray}, api.UserIds())
+}
@@ -0,0 +1,131 @@
+// Package identity maps the caller's own external IDs (customer numbers, account
+// IDs...) to the opaque userIds returned by CreateUser and keeps the mapping
+// consistent with the users that exist in VoiceIt
+package identity
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/store"
+)
+
+// Store persists the external ID to userId mapping. Implementations must be
+// safe for concurrent use
+type Store interface {
+	// Get returns the userId mapped to externalID. ok is false if there is none
+	Get(externalID string) (userId string, ok bool, err error)
+	// Put maps externalID to userId, replacing any previous mapping
+	Put(externalID string, userId string) error
+	// Delete removes the mapping for externalID. Deleting a missing mapping is not an error
+	Delete(externalID string) error
+	// List returns all mappings keyed by external ID
+	List() (map[string]string, error)
+}
+
+// MemoryStore is a Store kept in memory only
+type MemoryStore struct {
+	m store.Map[string]
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Get(externalID string) (string, bool, error) {
+	userId, ok := s.m.Get(externalID)
+	return userId, ok, nil
+}
+
+func (s *MemoryStore) Put(externalID string, userId string) error {
+	s.m.Put(externalID, userId)
+	return nil
+}
+
+func (s *MemoryStore) Delete(externalID string) error {
+	s.m.Delete(externalID)
+	return nil
+}
+
+func (s *MemoryStore) List() (map[string]string, error) {
+	return s.m.Copy(), nil
+}
+
+// FileStore is a Store kept in a JSON file. The whole mapping is held in memory
+// and the file is rewritten atomically on every change, which suits mappings of
+// up to a few hundred thousand entries
+type FileStore struct {
+	path string
+	mem  *MemoryStore
+	mu   sync.Mutex
+}
+
+// OpenFileStore loads the mapping from path, creating an empty store if the file does not exist
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, mem: NewMemoryStore()}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, errors.New("OpenFileStore error: " + err.Error())
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, errors.New("OpenFileStore error: " + path + ": " + err.Error())
+	}
+	s.mem.m.Replace(m)
+	return s, nil
+}
+
+func (s *FileStore) Get(externalID string) (string, bool, error) {
+	return s.mem.Get(externalID)
+}
+
+func (s *FileStore) Put(externalID string, userId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, existed, _ := s.mem.Get(externalID)
+	s.mem.Put(externalID, userId)
+	if err := s.save(); err != nil {
+		if existed {
+			s.mem.Put(externalID, previous)
+		} else {
+			s.mem.Delete(externalID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) Delete(externalID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, existed, _ := s.mem.Get(externalID)
+	if !existed {
+		return nil
+	}
+	s.mem.Delete(externalID)
+	if err := s.save(); err != nil {
+		s.mem.Put(externalID, previous)
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) List() (map[string]string, error) {
+	return s.mem.List()
+}
+
+// save rewrites the file atomically. The caller must hold s.mu
+func (s *FileStore) save() error {
+	data, err := json.MarshalIndent(s.mem.m.Copy(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile(s.path, data, 0600)
+}
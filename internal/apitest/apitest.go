@@ -0,0 +1,27 @@
+// Package apitest sets up voiceit2 clients against the fake API of package
+// fakeapi for the tests of the packages built on top of the client
+package apitest
+
+import (
+	"testing"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/fakeapi"
+)
+
+// Key and Token are the account credentials the fake API accepts
+const (
+	Key   = "key"
+	Token = "tok"
+)
+
+// New starts a fake API, closed when the test ends, and returns it with a
+// client holding its credentials whose base and liveness URLs point to it
+func New(t testing.TB) (*fakeapi.Server, voiceit2.VoiceIt2) {
+	api := fakeapi.New(Key, Token)
+	t.Cleanup(api.Close)
+	client := voiceit2.NewClient(Key, Token)
+	client.BaseUrl = api.URL
+	client.LivenessUrl = api.URL
+	return api, client
+}
@@ -0,0 +1,50 @@
+// Package apiutil holds helpers shared by the packages built on top of the voiceit2 client
+package apiutil
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// APIError is returned by Decode when the API answered with a responseCode other than SUCC
+type APIError struct {
+	Call         string
+	Status       int
+	ResponseCode string
+	Message      string
+}
+
+func (e *APIError) Error() string {
+	return e.Call + " failed with " + e.ResponseCode + " (" + strconv.Itoa(e.Status) + "): " + e.Message
+}
+
+// Temporary reports whether the failure was on the server side and the call may succeed if retried
+func (e *APIError) Temporary() bool {
+	return e.Status >= 500 || e.Status == 429
+}
+
+type apiReturn struct {
+	Message      string `json:"message"`
+	Status       int    `json:"status"`
+	ResponseCode string `json:"responseCode"`
+}
+
+// Decode takes the results of a client call and unmarshals a successful reply into v.
+// v may be nil when only the success of the call matters
+func Decode(call string, ret []byte, err error, v interface{}) error {
+	if err != nil {
+		return err
+	}
+	var ar apiReturn
+	if err := json.Unmarshal(ret, &ar); err != nil {
+		return errors.New(call + " returned invalid JSON: " + err.Error())
+	}
+	if ar.ResponseCode != "SUCC" {
+		return &APIError{Call: call, Status: ar.Status, ResponseCode: ar.ResponseCode, Message: ar.Message}
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(ret, v)
+}
@@ -0,0 +1,101 @@
+package apiutil
+
+import (
+	"encoding/json"
+	"errors"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+)
+
+// Match holds the fields of identification and verification replies
+type Match struct {
+	Status       int
+	ResponseCode string
+	Message      string
+	UserId       string
+	GroupId      string
+	// Confidence is the reply's confidence. For video, both the voice and the
+	// face confidence count, so it is the lower of the two
+	Confidence float64
+}
+
+// APIError returns the reply as an APIError of call
+func (m Match) APIError(call string) *APIError {
+	return &APIError{Call: call, Status: m.Status, ResponseCode: m.ResponseCode, Message: m.Message}
+}
+
+// Identify runs the identification of the modality and returns the name of the call made
+func Identify(vi voiceit2.VoiceIt2, modality voiceit2.Modality, groupId, contentLanguage, phrase, filePath string) (call string, ret []byte, err error) {
+	switch modality {
+	case voiceit2.Voice:
+		ret, err = vi.VoiceIdentification(groupId, contentLanguage, phrase, filePath)
+	case voiceit2.Face:
+		ret, err = vi.FaceIdentification(groupId, filePath)
+	case voiceit2.Video:
+		ret, err = vi.VideoIdentification(groupId, contentLanguage, phrase, filePath)
+	default:
+		return "", nil, errors.New("unknown modality \"" + string(modality) + "\"")
+	}
+	return callName(modality, "Identification"), ret, err
+}
+
+// Verify runs the verification of the modality and returns the name of the call made
+func Verify(vi voiceit2.VoiceIt2, modality voiceit2.Modality, userId, contentLanguage, phrase, filePath string) (call string, ret []byte, err error) {
+	switch modality {
+	case voiceit2.Voice:
+		ret, err = vi.VoiceVerification(userId, contentLanguage, phrase, filePath)
+	case voiceit2.Face:
+		ret, err = vi.FaceVerification(userId, filePath)
+	case voiceit2.Video:
+		ret, err = vi.VideoVerification(userId, contentLanguage, phrase, filePath)
+	default:
+		return "", nil, errors.New("unknown modality \"" + string(modality) + "\"")
+	}
+	return callName(modality, "Verification"), ret, err
+}
+
+// Enroll creates an enrollment of the modality and returns the name of the call made
+func Enroll(vi voiceit2.VoiceIt2, modality voiceit2.Modality, userId, contentLanguage, phrase, filePath string) (call string, ret []byte, err error) {
+	switch modality {
+	case voiceit2.Voice:
+		ret, err = vi.CreateVoiceEnrollment(userId, contentLanguage, phrase, filePath)
+	case voiceit2.Face:
+		ret, err = vi.CreateFaceEnrollment(userId, filePath)
+	case voiceit2.Video:
+		ret, err = vi.CreateVideoEnrollment(userId, contentLanguage, phrase, filePath)
+	default:
+		return "", nil, errors.New("unknown modality \"" + string(modality) + "\"")
+	}
+	return "Create" + callName(modality, "Enrollment"), ret, err
+}
+
+func callName(modality voiceit2.Modality, kind string) string {
+	m := string(modality)
+	return string(m[0]-'a'+'A') + m[1:] + kind
+}
+
+// DecodeMatch unmarshals an identification or verification reply of call
+func DecodeMatch(call string, ret []byte) (Match, error) {
+	var mr struct {
+		Message         string   `json:"message"`
+		Status          int      `json:"status"`
+		ResponseCode    string   `json:"responseCode"`
+		UserId          string   `json:"userId"`
+		GroupId         string   `json:"groupId"`
+		Confidence      *float64 `json:"confidence"`
+		VoiceConfidence *float64 `json:"voiceConfidence"`
+		FaceConfidence  *float64 `json:"faceConfidence"`
+	}
+	if err := json.Unmarshal(ret, &mr); err != nil {
+		return Match{}, errors.New(call + " returned invalid JSON: " + err.Error())
+	}
+	m := Match{Status: mr.Status, ResponseCode: mr.ResponseCode, Message: mr.Message, UserId: mr.UserId, GroupId: mr.GroupId}
+	first := true
+	for _, c := range []*float64{mr.Confidence, mr.VoiceConfidence, mr.FaceConfidence} {
+		if c != nil && (first || *c < m.Confidence) {
+			m.Confidence = *c
+			first = false
+		}
+	}
+	return m, nil
+}
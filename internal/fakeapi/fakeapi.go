@@ -0,0 +1,737 @@
+// Package fakeapi is an in-memory stand-in for the VoiceIt API 2.0 used by the
+// tests of the packages built on top of the voiceit2 client
+package fakeapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type user struct {
+	createdAt        int
+	voiceEnrollments []map[string]interface{}
+	faceEnrollments  []map[string]interface{}
+	videoEnrollments []map[string]interface{}
+	// prints holds the speaker print of each enrollment by kind, see Print
+	prints map[string][]string
+}
+
+type group struct {
+	createdAt   int
+	description string
+	users       []string
+}
+
+// Server is a fake VoiceIt API backed by in-memory state
+type Server struct {
+	*httptest.Server
+
+	APIKey   string
+	APIToken string
+
+	mu       sync.Mutex
+	nextId   int
+	users    map[string]*user
+	groups   map[string]*group
+	calls    []string
+	failures []*failure
+	tokens   map[string]userToken
+	// subAccounts maps sub-account API keys to their state. Sub-accounts share
+	// the fake's users and groups; only their credentials are separate
+	subAccounts map[string]*subAccount
+	// phrases maps content languages to the account's phrases
+	phrases map[string][]string
+	// challenges maps the lcoIds of unused liveness challenges to them
+	challenges map[string]challenge
+}
+
+type challenge struct {
+	userId  string
+	actions []string
+}
+
+type subAccount struct {
+	apiToken string
+	kind     string
+}
+
+type userToken struct {
+	userId  string
+	expires time.Time
+}
+
+type failure struct {
+	call   string
+	times  int
+	status int
+	after  bool
+}
+
+// New starts a fake API that accepts the given API key and token
+func New(apiKey string, apiToken string) *Server {
+	s := &Server{
+		APIKey:   apiKey,
+		APIToken: apiToken,
+		users:    map[string]*user{},
+		groups:   map[string]*group{},
+		tokens:   map[string]userToken{},
+
+		subAccounts: map[string]*subAccount{},
+		challenges:  map[string]challenge{},
+		phrases: map[string][]string{
+			"en-US": {"never forget tomorrow is a new day", "today is a nice day to go for a walk", "zoos are filled with small and large animals"},
+		},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Calls returns the "METHOD /path" of every request received so far
+func (s *Server) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.calls...)
+}
+
+// Fail makes the next times requests matching call ("METHOD /path") fail with
+// the given HTTP status without changing any state
+func (s *Server) Fail(call string, times int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, &failure{call: call, times: times, status: status})
+}
+
+// FailAfter is like Fail, but the request is processed before the failure is
+// returned, as if the reply had been lost on the way back
+func (s *Server) FailAfter(call string, times int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, &failure{call: call, times: times, status: status, after: true})
+}
+
+func (s *Server) takeFailure(call string) *failure {
+	for _, f := range s.failures {
+		if f.call == call && f.times > 0 {
+			f.times--
+			return f
+		}
+	}
+	return nil
+}
+
+// AddUser creates a user directly in the fake's state and returns its userId
+func (s *Server) AddUser() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addUser()
+}
+
+// AddGroup creates a group with the given members directly in the fake's state
+func (s *Server) AddGroup(description string, userIds ...string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.newId("grp_")
+	s.groups[id] = &group{createdAt: now(), description: description, users: append([]string{}, userIds...)}
+	return id
+}
+
+// UserIds returns the ids of all users in the fake's state
+func (s *Server) UserIds() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := []string{}
+	for id := range s.users {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// EnrollmentCount returns the number of enrollments of the given kind
+// ("voice", "face" or "video") for a user
+func (s *Server) EnrollmentCount(userId string, kind string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[userId]
+	if !ok {
+		return 0
+	}
+	return len(*u.enrollments(kind))
+}
+
+func (s *Server) addUser() string {
+	id := s.newId("usr_")
+	s.users[id] = &user{createdAt: now()}
+	return id
+}
+
+func (s *Server) newId(prefix string) string {
+	s.nextId++
+	return prefix + strconv.Itoa(s.nextId)
+}
+
+func now() int {
+	return int(time.Now().Unix())
+}
+
+func (u *user) enrollments(kind string) *[]map[string]interface{} {
+	switch kind {
+	case "voice":
+		return &u.voiceEnrollments
+	case "face":
+		return &u.faceEnrollments
+	}
+	return &u.videoEnrollments
+}
+
+type reply map[string]interface{}
+
+func (s *Server) write(w http.ResponseWriter, status int, code string, message string, fields reply) {
+	body := reply{"status": status, "responseCode": code, "message": message, "timeTaken": "0.001s"}
+	for k, v := range fields {
+		body[k] = v
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	call := r.Method + " " + r.URL.Path
+	s.calls = append(s.calls, call)
+
+	if !s.authorized(r) {
+		s.write(w, 401, "UNAC", "Unauthorized", nil)
+		return
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			s.write(w, 400, "MISP", "Malformed multipart body", nil)
+			return
+		}
+	}
+
+	if f := s.takeFailure(call); f != nil {
+		if f.after {
+			s.route(httptest.NewRecorder(), r)
+		}
+		s.write(w, f.status, "FAIL", "Injected failure", nil)
+		return
+	}
+	s.route(w, r)
+}
+
+// authorized accepts the account's API key and token, or a live user token
+// passed as the user name with an empty password
+func (s *Server) authorized(r *http.Request) bool {
+	key, tok, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	if key == s.APIKey && tok == s.APIToken {
+		return true
+	}
+	if sub, found := s.subAccounts[key]; found {
+		return tok == sub.apiToken
+	}
+	t, found := s.tokens[key]
+	return found && tok == "" && time.Now().Before(t.expires)
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	seg := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case seg[0] == "users":
+		s.serveUsers(w, r, seg)
+	case seg[0] == "groups":
+		s.serveGroups(w, r, seg)
+	case seg[0] == "enrollments":
+		s.serveEnrollments(w, r, seg)
+	case seg[0] == "subaccount":
+		s.serveSubAccounts(w, r, seg)
+	case seg[0] == "phrases":
+		s.servePhrases(w, r, seg)
+	case seg[0] == "verification" || seg[0] == "identification":
+		s.serveBiometrics(w, r, seg)
+	case seg[0] == "v1" && len(seg) > 1 && seg[1] == "verification":
+		s.serveLiveness(w, r, seg[1:])
+	default:
+		s.write(w, 404, "NFEF", "Endpoint not found", nil)
+	}
+}
+
+func (s *Server) serveUsers(w http.ResponseWriter, r *http.Request, seg []string) {
+	switch {
+	case len(seg) == 1 && r.Method == "GET":
+		users := []reply{}
+		for id, u := range s.users {
+			users = append(users, reply{"userId": id, "createdAt": u.createdAt})
+		}
+		s.write(w, 200, "SUCC", "Successfully got all users", reply{"count": len(users), "users": users})
+	case len(seg) == 1 && r.Method == "POST":
+		id := s.addUser()
+		s.write(w, 201, "SUCC", "Created user with userId : "+id, reply{"userId": id, "createdAt": s.users[id].createdAt})
+	case len(seg) == 2 && r.Method == "GET":
+		_, exists := s.users[seg[1]]
+		s.write(w, 200, "SUCC", "Checked user existence", reply{"exists": exists})
+	case len(seg) == 2 && r.Method == "DELETE":
+		if _, ok := s.users[seg[1]]; !ok {
+			s.write(w, 404, "UNFD", "User with userId : "+seg[1]+" not found", nil)
+			return
+		}
+		delete(s.users, seg[1])
+		for _, g := range s.groups {
+			g.users = without(g.users, seg[1])
+		}
+		s.write(w, 200, "SUCC", "Deleted user with userId : "+seg[1], nil)
+	case len(seg) == 3 && seg[2] == "token" && r.Method == "POST":
+		if _, ok := s.users[seg[1]]; !ok {
+			s.write(w, 404, "UNFD", "User with userId : "+seg[1]+" not found", nil)
+			return
+		}
+		timeout, _ := strconv.Atoi(r.URL.Query().Get("timeOut"))
+		if timeout <= 0 {
+			timeout = 3600
+		}
+		token := s.newId("utk_")
+		s.tokens[token] = userToken{userId: seg[1], expires: time.Now().Add(time.Duration(timeout) * time.Second)}
+		s.write(w, 201, "SUCC", "Successfully created new user token", reply{"userToken": token, "createdAt": now()})
+	case len(seg) == 3 && seg[2] == "expireTokens" && r.Method == "POST":
+		if _, ok := s.users[seg[1]]; !ok {
+			s.write(w, 404, "UNFD", "User with userId : "+seg[1]+" not found", nil)
+			return
+		}
+		for token, t := range s.tokens {
+			if t.userId == seg[1] {
+				delete(s.tokens, token)
+			}
+		}
+		s.write(w, 201, "SUCC", "Successfully expired all tokens for user with userId : "+seg[1], nil)
+	case len(seg) == 3 && seg[2] == "groups" && r.Method == "GET":
+		if _, ok := s.users[seg[1]]; !ok {
+			s.write(w, 404, "UNFD", "User with userId : "+seg[1]+" not found", nil)
+			return
+		}
+		groups := []string{}
+		for id, g := range s.groups {
+			if contains(g.users, seg[1]) {
+				groups = append(groups, id)
+			}
+		}
+		s.write(w, 200, "SUCC", "Successfully returned all groups for user", reply{"groups": groups, "count": len(groups)})
+	default:
+		s.write(w, 404, "NFEF", "Endpoint not found", nil)
+	}
+}
+
+func (s *Server) serveGroups(w http.ResponseWriter, r *http.Request, seg []string) {
+	switch {
+	case len(seg) == 1 && r.Method == "GET":
+		groups := []reply{}
+		for id, g := range s.groups {
+			groups = append(groups, reply{"groupId": id, "createdAt": g.createdAt, "description": g.description, "users": g.users, "userCount": len(g.users)})
+		}
+		s.write(w, 200, "SUCC", "Successfully got all groups", reply{"count": len(groups), "groups": groups})
+	case len(seg) == 1 && r.Method == "POST":
+		id := s.newId("grp_")
+		s.groups[id] = &group{createdAt: now(), description: r.FormValue("description"), users: []string{}}
+		s.write(w, 201, "SUCC", "Created group with groupId : "+id, reply{"groupId": id, "description": s.groups[id].description, "createdAt": s.groups[id].createdAt})
+	case len(seg) == 2 && (seg[1] == "addUser" || seg[1] == "removeUser") && r.Method == "PUT":
+		g, ok := s.groups[r.FormValue("groupId")]
+		if !ok {
+			s.write(w, 404, "GNFD", "Group not found", nil)
+			return
+		}
+		userId := r.FormValue("userId")
+		if _, ok := s.users[userId]; !ok {
+			s.write(w, 404, "UNFD", "User with userId : "+userId+" not found", nil)
+			return
+		}
+		if seg[1] == "addUser" {
+			if !contains(g.users, userId) {
+				g.users = append(g.users, userId)
+			}
+			s.write(w, 200, "SUCC", "Successfully added user to group", nil)
+			return
+		}
+		g.users = without(g.users, userId)
+		s.write(w, 200, "SUCC", "Successfully removed user from group", nil)
+	case len(seg) == 2 && r.Method == "GET":
+		g, ok := s.groups[seg[1]]
+		if !ok {
+			s.write(w, 404, "GNFD", "Group not found", nil)
+			return
+		}
+		s.write(w, 200, "SUCC", "Successfully returned group", reply{"groupId": seg[1], "createdAt": g.createdAt, "description": g.description, "users": g.users, "userCount": len(g.users)})
+	case len(seg) == 3 && seg[2] == "exists" && r.Method == "GET":
+		_, exists := s.groups[seg[1]]
+		s.write(w, 200, "SUCC", "Checked group existence", reply{"exists": exists})
+	case len(seg) == 2 && r.Method == "DELETE":
+		if _, ok := s.groups[seg[1]]; !ok {
+			s.write(w, 404, "GNFD", "Group not found", nil)
+			return
+		}
+		delete(s.groups, seg[1])
+		s.write(w, 200, "SUCC", "Successfully deleted group", nil)
+	default:
+		s.write(w, 404, "NFEF", "Endpoint not found", nil)
+	}
+}
+
+func (s *Server) serveEnrollments(w http.ResponseWriter, r *http.Request, seg []string) {
+	switch {
+	case len(seg) == 3 && seg[2] == "all" && r.Method == "DELETE":
+		u, ok := s.users[seg[1]]
+		if !ok {
+			s.write(w, 404, "UNFD", "User with userId : "+seg[1]+" not found", nil)
+			return
+		}
+		u.voiceEnrollments, u.faceEnrollments, u.videoEnrollments = nil, nil, nil
+		u.prints = nil
+		s.write(w, 200, "SUCC", "All enrollments for user deleted", nil)
+	case len(seg) == 3 && r.Method == "GET":
+		u, ok := s.users[seg[2]]
+		if !ok {
+			s.write(w, 404, "UNFD", "User with userId : "+seg[2]+" not found", nil)
+			return
+		}
+		list := *u.enrollments(seg[1])
+		s.write(w, 200, "SUCC", "Successfully got all "+seg[1]+" enrollments", reply{"count": len(list), seg[1] + "Enrollments": list})
+	case len(seg) == 2 && r.Method == "POST":
+		u, ok := s.users[r.FormValue("userId")]
+		if !ok {
+			s.write(w, 404, "UNFD", "User with userId : "+r.FormValue("userId")+" not found", nil)
+			return
+		}
+		field := mediaField(seg[1])
+		file, _, err := r.FormFile(field)
+		if err != nil {
+			s.write(w, 400, "MISP", "Missing "+field+" file", nil)
+			return
+		}
+		data, _ := ioutil.ReadAll(file)
+		if len(data) == 0 {
+			s.write(w, 400, "FNFD", "Empty media file", nil)
+			return
+		}
+		s.nextId++
+		id := s.nextId
+		e := map[string]interface{}{"createdAt": now(), seg[1] + "EnrollmentId": id}
+		if seg[1] != "face" {
+			e["contentLanguage"] = r.FormValue("contentLanguage")
+			e["text"] = r.FormValue("phrase")
+		}
+		list := u.enrollments(seg[1])
+		*list = append(*list, e)
+		if u.prints == nil {
+			u.prints = map[string][]string{}
+		}
+		u.prints[seg[1]] = append(u.prints[seg[1]], Print(data))
+		fields := reply{"id": id, "createdAt": e["createdAt"]}
+		if seg[1] == "face" {
+			fields = reply{"faceEnrollmentId": id, "createdAt": e["createdAt"]}
+		} else {
+			fields["contentLanguage"] = e["contentLanguage"]
+			fields["text"] = e["text"]
+			fields["textConfidence"] = 100.0
+		}
+		s.write(w, 201, "SUCC", "Successfully enrolled "+seg[1]+" for user", fields)
+	default:
+		s.write(w, 404, "NFEF", "Endpoint not found", nil)
+	}
+}
+
+// SubAccountToken returns the current API token of a sub-account
+func (s *Server) SubAccountToken(apiKey string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub, ok := s.subAccounts[apiKey]; ok {
+		return sub.apiToken
+	}
+	return ""
+}
+
+func (s *Server) serveSubAccounts(w http.ResponseWriter, r *http.Request, seg []string) {
+	switch {
+	case len(seg) == 2 && (seg[1] == "managed" || seg[1] == "unmanaged") && r.Method == "POST":
+		key := s.newId("key_")
+		sub := &subAccount{apiToken: s.newId("tok_"), kind: seg[1]}
+		s.subAccounts[key] = sub
+		s.write(w, 201, "SUCC", "Successfully created new "+seg[1]+" sub-account", reply{
+			"apiKey": key, "apiToken": sub.apiToken, "type": seg[1], "email": r.FormValue("email"),
+			"contentLanguage": r.FormValue("contentLanguage"), "emailValidationRequired": false,
+		})
+	case len(seg) >= 2:
+		sub, ok := s.subAccounts[seg[1]]
+		if !ok {
+			s.write(w, 404, "ACNF", "Sub-account not found", nil)
+			return
+		}
+		switch {
+		case len(seg) == 2 && r.Method == "POST":
+			sub.apiToken = s.newId("tok_")
+			s.write(w, 200, "SUCC", "Successfully regenerated API token", reply{"apiToken": sub.apiToken})
+		case len(seg) == 2 && r.Method == "DELETE":
+			delete(s.subAccounts, seg[1])
+			s.write(w, 200, "SUCC", "Successfully deleted sub-account", nil)
+		case len(seg) == 3 && seg[2] == "switchType" && r.Method == "POST":
+			if sub.kind == "managed" {
+				sub.kind = "unmanaged"
+			} else {
+				sub.kind = "managed"
+			}
+			s.write(w, 200, "SUCC", "Successfully switched sub-account type", reply{"type": sub.kind})
+		default:
+			s.write(w, 404, "NFEF", "Endpoint not found", nil)
+		}
+	default:
+		s.write(w, 404, "NFEF", "Endpoint not found", nil)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func without(list []string, s string) []string {
+	out := []string{}
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Print returns the part of a media file the fake treats as the identity of the
+// person in it: everything up to the first newline. Files with the same first
+// line match each other during verification and identification
+func Print(data []byte) string {
+	if i := strings.IndexByte(string(data), '\n'); i >= 0 {
+		return string(data[:i])
+	}
+	return string(data)
+}
+
+// mediaField returns the multipart field holding the media of an enrollment,
+// verification or identification
+func mediaField(kind string) string {
+	if kind == "voice" {
+		return "recording"
+	}
+	return "video"
+}
+
+// score returns the confidence of matching print against the user's enrollments
+// of a kind: 95 for a matching print, 40 otherwise
+func (u *user) score(kind string, print string) float64 {
+	for _, p := range u.prints[kind] {
+		if p == print {
+			return 95
+		}
+	}
+	return 40
+}
+
+// Transcript returns what the fake hears in a media file: its second line, or
+// phrase if the file has a single line
+func Transcript(data []byte, phrase string) string {
+	lines := strings.SplitN(string(data), "\n", 3)
+	if len(lines) < 2 {
+		return phrase
+	}
+	return lines[1]
+}
+
+// confidenceFields names the confidence of a match the way the API does for each
+// kind. The text confidence is 100 when the transcript is the expected phrase
+func confidenceFields(kind string, confidence float64, text string, phrase string) reply {
+	textConfidence := 100.0
+	if !strings.EqualFold(text, phrase) {
+		textConfidence = 20
+	}
+	switch kind {
+	case "voice":
+		return reply{"confidence": confidence, "text": text, "textConfidence": textConfidence}
+	case "face":
+		return reply{"faceConfidence": confidence}
+	}
+	return reply{"voiceConfidence": confidence, "faceConfidence": confidence, "text": text, "textConfidence": textConfidence}
+}
+
+// SetPhrases replaces the account's phrases for a content language
+func (s *Server) SetPhrases(contentLanguage string, phrases ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phrases[contentLanguage] = phrases
+}
+
+func (s *Server) servePhrases(w http.ResponseWriter, r *http.Request, seg []string) {
+	if len(seg) != 2 || r.Method != "GET" {
+		s.write(w, 404, "NFEF", "Endpoint not found", nil)
+		return
+	}
+	list := []reply{}
+	for _, text := range s.phrases[seg[1]] {
+		list = append(list, reply{"text": text, "contentLanguage": seg[1]})
+	}
+	s.write(w, 200, "SUCC", "Successfully got all "+seg[1]+" phrases for account", reply{"count": len(list), "phrases": list})
+}
+
+func (s *Server) serveBiometrics(w http.ResponseWriter, r *http.Request, seg []string) {
+	if len(seg) != 2 || r.Method != "POST" || (seg[1] != "voice" && seg[1] != "face" && seg[1] != "video") {
+		s.write(w, 404, "NFEF", "Endpoint not found", nil)
+		return
+	}
+	kind := seg[1]
+	file, _, err := r.FormFile(mediaField(kind))
+	if err != nil {
+		s.write(w, 400, "MISP", "Missing "+mediaField(kind)+" file", nil)
+		return
+	}
+	data, _ := ioutil.ReadAll(file)
+	if len(data) == 0 {
+		s.write(w, 400, "FNFD", "Empty media file", nil)
+		return
+	}
+	print := Print(data)
+	phrase := r.FormValue("phrase")
+	text := Transcript(data, phrase)
+
+	if seg[0] == "verification" {
+		userId := r.FormValue("userId")
+		u, ok := s.users[userId]
+		if !ok {
+			s.write(w, 404, "UNFD", "User with userId : "+userId+" not found", nil)
+			return
+		}
+		if len(u.prints[kind]) == 0 {
+			s.write(w, 400, "NEHSD", "User has no "+kind+" enrollments", nil)
+			return
+		}
+		confidence := u.score(kind, print)
+		if confidence < 70 {
+			s.write(w, 200, "FAIL", "Failed to verify user", confidenceFields(kind, confidence, text, phrase))
+			return
+		}
+		s.write(w, 200, "SUCC", "Successfully verified user", confidenceFields(kind, confidence, text, phrase))
+		return
+	}
+
+	groupId := r.FormValue("groupId")
+	g, ok := s.groups[groupId]
+	if !ok {
+		s.write(w, 404, "GNFD", "Group with groupId : "+groupId+" not found", nil)
+		return
+	}
+	members := append([]string(nil), g.users...)
+	sort.Strings(members)
+	for _, userId := range members {
+		if u, ok := s.users[userId]; ok && u.score(kind, print) >= 70 {
+			fields := confidenceFields(kind, u.score(kind, print), text, phrase)
+			fields["userId"] = userId
+			fields["groupId"] = groupId
+			s.write(w, 200, "SUCC", "Successfully identified user "+userId+" in group "+groupId, fields)
+			return
+		}
+	}
+	fields := confidenceFields(kind, 0, text, phrase)
+	fields["groupId"] = groupId
+	s.write(w, 200, "FAIL", "Failed to identify user in group "+groupId, fields)
+}
+
+// livenessActions are the actions the fake asks for in liveness challenges
+var livenessActions = []string{"FACE_LEFT", "FACE_RIGHT", "FACE_UP", "FACE_DOWN", "SMILE", "BLINK"}
+
+// serveLiveness serves the liveness endpoints, which the client reaches under
+// its LivenessUrl. A sample passes the challenge if its third line, see
+// Actions, lists the challenge's actions in order separated by spaces
+func (s *Server) serveLiveness(w http.ResponseWriter, r *http.Request, seg []string) {
+	switch {
+	case len(seg) == 3 && r.Method == "GET":
+		if _, ok := s.users[seg[1]]; !ok {
+			s.write(w, 404, "UNFD", "User with userId : "+seg[1]+" not found", reply{"success": false})
+			return
+		}
+		lcoId := s.newId("lco_")
+		n := s.nextId
+		actions := []string{livenessActions[n%len(livenessActions)], livenessActions[(n+1)%len(livenessActions)]}
+		s.challenges[lcoId] = challenge{userId: seg[1], actions: actions}
+		s.write(w, 200, "SUCC", "Successfully created liveness challenge", reply{
+			"success": true, "lcoId": lcoId, "lco": actions,
+			"uiLivenessInstruction": "Please " + strings.ToLower(strings.Join(actions, " then ")),
+		})
+	case len(seg) == 2 && (seg[1] == "face" || seg[1] == "video" || seg[1] == "audio") && r.Method == "POST":
+		userId := r.FormValue("userId")
+		u, ok := s.users[userId]
+		if !ok {
+			s.write(w, 404, "UNFD", "User with userId : "+userId+" not found", reply{"success": false})
+			return
+		}
+		c, ok := s.challenges[r.FormValue("lcoId")]
+		if !ok || c.userId != userId {
+			s.write(w, 400, "LCNF", "Liveness challenge not found or already used", reply{"success": false})
+			return
+		}
+		delete(s.challenges, r.FormValue("lcoId"))
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			s.write(w, 400, "MISP", "Missing file", reply{"success": false})
+			return
+		}
+		data, _ := ioutil.ReadAll(file)
+		kind := seg[1]
+		if kind == "audio" {
+			kind = "voice"
+		}
+		if len(*u.enrollments(kind)) == 0 {
+			s.write(w, 400, "NEHSD", "Not enough enrollments", reply{"success": false})
+			return
+		}
+
+		confidence := u.score(kind, Print(data))
+		fields := reply{}
+		if kind != "voice" {
+			fields["faceConfidence"] = confidence
+		}
+		if kind != "face" {
+			fields["voiceConfidence"] = confidence
+		}
+		switch {
+		case Actions(data) != strings.Join(c.actions, " "):
+			fields["success"] = false
+			s.write(w, 200, "LDFA", "Liveness detection failed", fields)
+		case confidence < 90:
+			fields["success"] = false
+			s.write(w, 200, "FAIL", "Liveness passed but verification failed", fields)
+		default:
+			fields["success"] = true
+			s.write(w, 200, "SUCC", "Successfully verified "+seg[1]+" liveness", fields)
+		}
+	default:
+		s.write(w, 404, "NFEF", "Endpoint not found", nil)
+	}
+}
+
+// Actions returns the liveness actions the fake sees performed in a media file: its third line
+func Actions(data []byte) string {
+	lines := strings.SplitN(string(data), "\n", 4)
+	if len(lines) < 3 {
+		return ""
+	}
+	return lines[2]
+}
@@ -0,0 +1,104 @@
+// Package store holds the storage helpers shared by the packages that keep
+// state in memory or on disk
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Map is a map from string keys safe for concurrent use. The zero value is an empty Map
+type Map[V any] struct {
+	mu sync.RWMutex
+	m  map[string]V
+}
+
+// Get returns the value of key and whether it is set
+func (m *Map[V]) Get(key string) (V, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.m[key]
+	return v, ok
+}
+
+// Put sets the value of key
+func (m *Map[V]) Put(key string, v V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.m == nil {
+		m.m = map[string]V{}
+	}
+	m.m[key] = v
+}
+
+// Delete removes key
+func (m *Map[V]) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.m, key)
+}
+
+// Keys returns the keys in sorted order
+func (m *Map[V]) Keys() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]string, 0, len(m.m))
+	for k := range m.m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Copy returns a copy of the contents
+func (m *Map[V]) Copy() map[string]V {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(map[string]V, len(m.m))
+	for k, v := range m.m {
+		out[k] = v
+	}
+	return out
+}
+
+// Replace replaces the contents with a copy of contents
+func (m *Map[V]) Replace(contents map[string]V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.m = make(map[string]V, len(contents))
+	for k, v := range contents {
+		m.m[k] = v
+	}
+}
+
+// WriteFile writes data to path through a uniquely named temporary file in the
+// same directory, so readers and a crash never see a partly written file and
+// concurrent writers do not share a temporary file
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
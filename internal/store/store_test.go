@@ -0,0 +1,53 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFile(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "store")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	// Concurrent writers each use their own temporary file, so every write lands whole
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.Equal(nil, WriteFile(path, []byte("writer "+strconv.Itoa(i)), 0600))
+		}(i)
+	}
+	wg.Wait()
+	data, _ := ioutil.ReadFile(path)
+	assert.Regexp(`^writer \d+$`, string(data))
+	entries, _ := ioutil.ReadDir(dir)
+	assert.Equal(1, len(entries), "no temporary files are left behind")
+	assert.Equal(os.FileMode(0600), entries[0].Mode().Perm())
+
+	assert.NotEqual(nil, WriteFile(filepath.Join(dir, "missing", "state.json"), nil, 0600))
+}
+
+func TestMap(t *testing.T) {
+	assert := assert.New(t)
+	var m Map[int]
+	_, ok := m.Get("a")
+	assert.False(ok)
+	m.Put("b", 2)
+	m.Put("a", 1)
+	assert.Equal([]string{"a", "b"}, m.Keys())
+	m.Delete("b")
+	copied := m.Copy()
+	copied["c"] = 3
+	assert.Equal(map[string]int{"a": 1}, m.Copy())
+	m.Replace(nil)
+	assert.Equal(0, len(m.Keys()))
+}
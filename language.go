@@ -0,0 +1,190 @@
+package voiceit2
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ContentLanguage is a BCP-47 tag of a language supported by VoiceIt for voice
+// and video enrollments, verifications and identifications
+// For more details see https://api.voiceit.io/#content-languages
+type ContentLanguage string
+
+// NoSTT skips speech-to-text, so any phrase is accepted
+const NoSTT ContentLanguage = "no-STT"
+
+// supportedContentLanguages lists the content languages of the API documentation
+var supportedContentLanguages = []ContentLanguage{
+	NoSTT,
+	"af-ZA", "am-ET", "ar-AE", "ar-BH", "ar-DZ", "ar-EG", "ar-IL", "ar-IQ", "ar-JO",
+	"ar-KW", "ar-LB", "ar-MA", "ar-OM", "ar-PS", "ar-QA", "ar-SA", "ar-TN", "az-AZ",
+	"bg-BG", "bn-BD", "bn-IN", "ca-ES", "cmn-Hans-CN", "cmn-Hans-HK", "cmn-Hant-TW",
+	"cs-CZ", "da-DK", "de-DE", "el-GR", "en-AU", "en-CA", "en-GB", "en-GH", "en-IE",
+	"en-IN", "en-KE", "en-NG", "en-NZ", "en-PH", "en-TZ", "en-US", "en-ZA", "es-AR",
+	"es-BO", "es-CL", "es-CO", "es-CR", "es-DO", "es-EC", "es-ES", "es-GT", "es-HN",
+	"es-MX", "es-NI", "es-PA", "es-PE", "es-PR", "es-PY", "es-SV", "es-US", "es-UY",
+	"es-VE", "eu-ES", "fa-IR", "fi-FI", "fil-PH", "fr-CA", "fr-FR", "gl-ES", "gu-IN",
+	"he-IL", "hi-IN", "hr-HR", "hu-HU", "hy-AM", "id-ID", "is-IS", "it-IT", "ja-JP",
+	"jv-ID", "ka-GE", "km-KH", "kn-IN", "ko-KR", "lo-LA", "lt-LT", "lv-LV", "ml-IN",
+	"mr-IN", "ms-MY", "nb-NO", "ne-NP", "nl-NL", "pl-PL", "pt-BR", "pt-PT", "ro-RO",
+	"ru-RU", "si-LK", "sk-SK", "sl-SI", "sr-RS", "su-ID", "sv-SE", "sw-KE", "sw-TZ",
+	"ta-IN", "ta-LK", "ta-MY", "ta-SG", "te-IN", "th-TH", "tr-TR", "uk-UA", "ur-IN",
+	"ur-PK", "vi-VN", "yue-Hant-HK", "zu-ZA",
+}
+
+// fallbackRegions is the language used for a tag without a supported region,
+// such as "en" or "fr-BE", when the language has several
+var fallbackRegions = map[string]ContentLanguage{
+	"ar": "ar-SA", "bn": "bn-IN", "cmn": "cmn-Hans-CN", "en": "en-US", "es": "es-ES",
+	"fr": "fr-FR", "pt": "pt-BR", "sw": "sw-KE", "ta": "ta-IN", "ur": "ur-PK",
+	"zh": "cmn-Hans-CN",
+}
+
+// fallbackVariants is the language used for a tag whose script or region
+// decides between the variants of a language, such as zh-TW. They are tried
+// before fallbackRegions, script first
+var fallbackVariants = map[string]ContentLanguage{
+	"cmn-Hans": "cmn-Hans-CN", "cmn-Hant": "cmn-Hant-TW", "cmn-CN": "cmn-Hans-CN",
+	"cmn-HK": "cmn-Hans-HK", "cmn-TW": "cmn-Hant-TW",
+	"zh-Hans": "cmn-Hans-CN", "zh-Hant": "cmn-Hant-TW", "zh-CN": "cmn-Hans-CN",
+	"zh-HK": "cmn-Hans-HK", "zh-TW": "cmn-Hant-TW",
+}
+
+var contentLanguagesByKey = func() map[string]ContentLanguage {
+	m := map[string]ContentLanguage{}
+	for _, l := range supportedContentLanguages {
+		m[strings.ToLower(string(l))] = l
+	}
+	return m
+}()
+
+// SupportedContentLanguages returns the supported content languages in sorted order
+func SupportedContentLanguages() []ContentLanguage {
+	list := append([]ContentLanguage(nil), supportedContentLanguages...)
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+// UnsupportedContentLanguageError is returned for tags that are not supported content languages
+type UnsupportedContentLanguageError struct {
+	Tag string
+}
+
+func (e *UnsupportedContentLanguageError) Error() string {
+	return "unsupported content language \"" + e.Tag + "\""
+}
+
+// ParseContentLanguage normalizes a BCP-47 tag, for example en_us to en-US, and
+// checks that it is a supported content language
+func ParseContentLanguage(tag string) (ContentLanguage, error) {
+	normalized, err := normalizeTag(tag)
+	if err != nil {
+		return "", err
+	}
+	if l, ok := contentLanguagesByKey[strings.ToLower(normalized)]; ok {
+		return l, nil
+	}
+	return "", &UnsupportedContentLanguageError{Tag: tag}
+}
+
+// ResolveContentLanguage is ParseContentLanguage, except that a tag of a
+// supported language with an unsupported or missing region falls back to a
+// supported region of that language, for example fr-BE to fr-FR. Chinese tags
+// resolve by script, then region, for example zh-Hant to cmn-Hant-TW
+func ResolveContentLanguage(tag string) (ContentLanguage, error) {
+	l, err := ParseContentLanguage(tag)
+	if err == nil {
+		return l, nil
+	}
+	normalized, nerr := normalizeTag(tag)
+	if nerr != nil {
+		return "", nerr
+	}
+	subtags := strings.Split(normalized, "-")
+	language := subtags[0]
+	for _, subtag := range subtags[1:] {
+		if l, ok := fallbackVariants[language+"-"+subtag]; ok {
+			return l, nil
+		}
+	}
+	if l, ok := fallbackRegions[language]; ok {
+		return l, nil
+	}
+	// A language supported in a single region
+	var only ContentLanguage
+	for _, l := range supportedContentLanguages {
+		if strings.HasPrefix(string(l), language+"-") {
+			if only != "" {
+				return "", err
+			}
+			only = l
+		}
+	}
+	if only == "" || only == NoSTT {
+		return "", err
+	}
+	return only, nil
+}
+
+// Valid reports whether l is a supported content language as written
+func (l ContentLanguage) Valid() bool {
+	supported, ok := contentLanguagesByKey[strings.ToLower(string(l))]
+	return ok && supported == l
+}
+
+func (l ContentLanguage) String() string {
+	return string(l)
+}
+
+// normalizeTag rewrites a BCP-47 tag with "-" separators and the usual case of
+// each subtag: language in lower case, script in title case and region in upper case
+func normalizeTag(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if strings.EqualFold(tag, string(NoSTT)) || strings.EqualFold(tag, "no_stt") {
+		return string(NoSTT), nil
+	}
+	subtags := strings.FieldsFunc(tag, func(r rune) bool { return r == '-' || r == '_' })
+	if len(subtags) == 0 {
+		return "", errors.New("empty content language")
+	}
+	for i, s := range subtags {
+		for _, r := range s {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+				return "", errors.New("malformed content language \"" + tag + "\"")
+			}
+		}
+		switch {
+		case i == 0:
+			subtags[i] = strings.ToLower(s)
+		case len(s) == 4:
+			subtags[i] = strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+		case len(s) == 2 || len(s) == 3:
+			subtags[i] = strings.ToUpper(s)
+		default:
+			subtags[i] = strings.ToLower(s)
+		}
+	}
+	return strings.Join(subtags, "-"), nil
+}
+
+// ContentLanguageFor returns the content language the client methods send for
+// the argument. An empty argument is replaced by the client's
+// DefaultContentLanguage and is sent as is if that is empty too. Other values
+// are normalized and must be supported unless AllowUnknownContentLanguages is set
+func (vi VoiceIt2) ContentLanguageFor(contentLanguage string) (string, error) {
+	if contentLanguage == "" {
+		contentLanguage = string(vi.DefaultContentLanguage)
+	}
+	if contentLanguage == "" {
+		return "", nil
+	}
+	l, err := ParseContentLanguage(contentLanguage)
+	if err != nil {
+		if vi.AllowUnknownContentLanguages {
+			return contentLanguage, nil
+		}
+		return "", err
+	}
+	return string(l), nil
+}
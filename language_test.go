@@ -0,0 +1,109 @@
+package voiceit2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseContentLanguage(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		tag      string
+		parsed   ContentLanguage
+		resolved ContentLanguage
+	}{
+		{"en-US", "en-US", "en-US"},
+		{"en_us", "en-US", "en-US"},
+		{" EN-us ", "en-US", "en-US"},
+		{"cmn-hans-cn", "cmn-Hans-CN", "cmn-Hans-CN"},
+		{"YUE_HANT_HK", "yue-Hant-HK", "yue-Hant-HK"},
+		{"no_stt", NoSTT, NoSTT},
+		{"NO-STT", NoSTT, NoSTT},
+		// Unsupported regions and bare languages only resolve
+		{"en", "", "en-US"},
+		{"fr-BE", "", "fr-FR"},
+		{"de-AT", "", "de-DE"},
+		{"zh", "", "cmn-Hans-CN"},
+		// Chinese resolves by script, then region
+		{"zh-TW", "", "cmn-Hant-TW"},
+		{"zh_hant", "", "cmn-Hant-TW"},
+		{"zh-Hant-HK", "", "cmn-Hant-TW"},
+		{"zh-HK", "", "cmn-Hans-HK"},
+		{"zh-Hans", "", "cmn-Hans-CN"},
+		{"zh-CN", "", "cmn-Hans-CN"},
+		{"zh-SG", "", "cmn-Hans-CN"},
+		{"cmn-TW", "", "cmn-Hant-TW"},
+		// Unknown languages and malformed tags do neither
+		{"xx-YY", "", ""},
+		{"no", "", ""},
+		{"en US", "", ""},
+		{"", "", ""},
+	}
+	for _, c := range cases {
+		parsed, err := ParseContentLanguage(c.tag)
+		assert.Equal(c.parsed, parsed, "ParseContentLanguage(%q)", c.tag)
+		assert.Equal(c.parsed == "", err != nil, "ParseContentLanguage(%q) error: %v", c.tag, err)
+		resolved, err := ResolveContentLanguage(c.tag)
+		assert.Equal(c.resolved, resolved, "ResolveContentLanguage(%q)", c.tag)
+		assert.Equal(c.resolved == "", err != nil, "ResolveContentLanguage(%q) error: %v", c.tag, err)
+	}
+
+	_, err := ParseContentLanguage("xx-YY")
+	unsupported, ok := err.(*UnsupportedContentLanguageError)
+	assert.True(ok, "%v", err)
+	assert.Equal("xx-YY", unsupported.Tag)
+
+	assert.True(ContentLanguage("en-US").Valid())
+	assert.False(ContentLanguage("en-us").Valid(), "Valid() expects the normalized tag")
+	assert.False(ContentLanguage("xx-YY").Valid())
+	for _, l := range SupportedContentLanguages() {
+		parsed, err := ParseContentLanguage(string(l))
+		assert.Equal(nil, err)
+		assert.Equal(l, parsed)
+	}
+}
+
+func TestClientContentLanguage(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		defaultLanguage ContentLanguage
+		allowUnknown    bool
+		argument        string
+		sent            string
+		fails           bool
+	}{
+		{"", false, "", "", false},
+		{"", false, "en_us", "en-US", false},
+		{"fr-FR", false, "", "fr-FR", false},
+		{"fr-FR", false, "de_de", "de-DE", false},
+		{"fr_fr", false, "", "fr-FR", false},
+		{"", false, "xx-YY", "", true},
+		{"xx-YY", false, "", "", true},
+		{"", true, "xx-YY", "xx-YY", false},
+		{"", true, "en_us", "en-US", false},
+	}
+	for _, c := range cases {
+		vi := NewClient("key", "tok")
+		vi.DefaultContentLanguage = c.defaultLanguage
+		vi.AllowUnknownContentLanguages = c.allowUnknown
+		sent, err := vi.ContentLanguageFor(c.argument)
+		assert.Equal(c.sent, sent, "%+v", c)
+		assert.Equal(c.fails, err != nil, "%+v: %v", c, err)
+	}
+}
+
+func TestDefaultContentLanguage(t *testing.T) {
+	assert := assert.New(t)
+	api, myVoiceIt := newFakeClient(t)
+	myVoiceIt.DefaultContentLanguage = "fr-FR"
+
+	myVoiceIt.GetPhrases("")
+	myVoiceIt.GetPhrases("en_us")
+	calls := api.Calls()
+	assert.Equal([]string{"GET /phrases/fr-FR", "GET /phrases/en-US"}, calls[len(calls)-2:])
+
+	_, err := myVoiceIt.GetPhrases("xx-YY")
+	assert.Contains(err.Error(), "unsupported content language")
+	assert.Equal(len(calls), len(api.Calls()), "unsupported content languages are not sent")
+}
@@ -0,0 +1,137 @@
+// Package liveness runs liveness verifications as sessions: a challenge is
+// fetched for the user, the user performs its instructions on camera or
+// microphone, and the recording is verified against the challenge
+package liveness
+
+import (
+	"encoding/json"
+	"errors"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apiutil"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+var (
+	// ErrSessionFinished is returned when a session's recording was already verified
+	ErrSessionFinished = errors.New("liveness session already finished")
+	// ErrUnknownModality is returned for modalities other than Face, Video and Voice
+	ErrUnknownModality = errors.New("unknown liveness modality")
+)
+
+// Session is a liveness challenge issued to a user and, once finished, its result
+type Session struct {
+	UserId          string            `json:"userId"`
+	ContentLanguage string            `json:"contentLanguage"`
+	Modality        voiceit2.Modality `json:"modality"`
+	// LcoId identifies the challenge to the API
+	LcoId string `json:"lcoId"`
+	// Instructions are the actions the user must perform, in order
+	Instructions []string `json:"instructions"`
+	// Prompt is the API's instruction text to show the user
+	Prompt string  `json:"prompt"`
+	Result *Result `json:"result,omitempty"`
+}
+
+// Result is the outcome of a liveness verification
+type Result struct {
+	// Passed is true when the user performed the instructions and was verified
+	Passed          bool    `json:"passed"`
+	ResponseCode    string  `json:"responseCode"`
+	Message         string  `json:"message"`
+	FaceConfidence  float64 `json:"faceConfidence"`
+	VoiceConfidence float64 `json:"voiceConfidence"`
+	// Response is the API reply
+	Response []byte `json:"-"`
+}
+
+// resultCodes are the responseCodes of a completed liveness verification: a
+// pass, a failed liveness check and a failed biometric match
+var resultCodes = map[string]bool{"SUCC": true, "LDFA": true, "FAIL": true}
+
+// Service starts and finishes liveness sessions
+type Service struct {
+	Client voiceit2.VoiceIt2
+	// ContentLanguage is the content language of the challenges. Defaults to the
+	// client's DefaultContentLanguage, or en-US
+	ContentLanguage string
+	// Phrase is the phrase spoken in video sessions
+	Phrase string
+}
+
+// New returns a Service for the client
+func New(client voiceit2.VoiceIt2) *Service {
+	return &Service{Client: client}
+}
+
+func (s *Service) contentLanguage() string {
+	switch {
+	case s.ContentLanguage != "":
+		return s.ContentLanguage
+	case s.Client.DefaultContentLanguage != "":
+		return string(s.Client.DefaultContentLanguage)
+	}
+	return "en-US"
+}
+
+// Start fetches a liveness challenge for the user with GetLivenessChallenge
+func (s *Service) Start(userId string, modality voiceit2.Modality) (*Session, error) {
+	if !modality.Valid() {
+		return nil, ErrUnknownModality
+	}
+	contentLanguage := s.contentLanguage()
+	ret, err := s.Client.GetLivenessChallenge(userId, contentLanguage)
+	var lc structs.LivenessChallengeReturn
+	if err := apiutil.Decode("GetLivenessChallenge", ret, err, &lc); err != nil {
+		return nil, errors.New("Start error: " + err.Error())
+	}
+	return &Session{
+		UserId:          userId,
+		ContentLanguage: contentLanguage,
+		Modality:        modality,
+		LcoId:           lc.LcoId,
+		Instructions:    lc.Lco,
+		Prompt:          lc.UILivenessInstruction,
+	}, nil
+}
+
+// Finish verifies the recording of the user performing the session's
+// instructions and records the result in the session. A rejected recording is
+// a result that did not pass, not an error
+func (s *Service) Finish(session *Session, filePath string) (*Result, error) {
+	if session.Result != nil {
+		return nil, ErrSessionFinished
+	}
+	var ret []byte
+	var err error
+	switch session.Modality {
+	case voiceit2.Face:
+		ret, err = s.Client.FaceLivenessVerification(session.UserId, session.LcoId, filePath)
+	case voiceit2.Video:
+		ret, err = s.Client.VideoLivenessVerification(session.UserId, session.ContentLanguage, s.Phrase, session.LcoId, filePath)
+	case voiceit2.Voice:
+		ret, err = s.Client.VoiceLivenessVerification(session.UserId, session.ContentLanguage, session.LcoId, filePath)
+	default:
+		return nil, ErrUnknownModality
+	}
+	if err != nil {
+		return nil, errors.New("Finish error: " + err.Error())
+	}
+	var lv structs.LivenessVerificationReturn
+	if err := json.Unmarshal(ret, &lv); err != nil {
+		return nil, errors.New("Finish error: " + err.Error())
+	}
+	// Rejections are results, other failures such as an unknown user are errors
+	if !resultCodes[lv.ResponseCode] {
+		return nil, errors.New("Finish error: " + (&apiutil.APIError{Call: "LivenessVerification", Status: lv.Status, ResponseCode: lv.ResponseCode, Message: lv.Message}).Error())
+	}
+	session.Result = &Result{
+		Passed:          lv.ResponseCode == "SUCC" && lv.Success,
+		ResponseCode:    lv.ResponseCode,
+		Message:         lv.Message,
+		FaceConfidence:  lv.FaceConfidence,
+		VoiceConfidence: lv.VoiceConfidence,
+		Response:        ret,
+	}
+	return session.Result, nil
+}
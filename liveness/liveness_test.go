@@ -0,0 +1,68 @@
+package liveness
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apitest"
+)
+
+func TestSessions(t *testing.T) {
+	assert := assert.New(t)
+	api, myVoiceIt := apitest.New(t)
+	dir, _ := ioutil.TempDir("", "liveness")
+	defer os.RemoveAll(dir)
+	sample := func(content string) string {
+		f, _ := ioutil.TempFile(dir, "sample")
+		f.WriteString(content)
+		f.Close()
+		return f.Name()
+	}
+
+	userId := api.AddUser()
+	_, err := myVoiceIt.CreateFaceEnrollment(userId, sample("ann\n"))
+	assert.Equal(nil, err)
+
+	s := New(myVoiceIt)
+	session, err := s.Start(userId, voiceit2.Face)
+	assert.Equal(nil, err)
+	assert.Equal("en-US", session.ContentLanguage)
+	assert.NotEqual("", session.LcoId)
+	assert.Equal(2, len(session.Instructions))
+	assert.NotEqual("", session.Prompt)
+	result, err := s.Finish(session, sample("ann\n\n"+strings.Join(session.Instructions, " ")))
+	assert.Equal(nil, err)
+	assert.True(result.Passed)
+	assert.Equal(95.0, result.FaceConfidence)
+	assert.Equal(result, session.Result)
+	_, err = s.Finish(session, sample("ann\n\n"+strings.Join(session.Instructions, " ")))
+	assert.Equal(ErrSessionFinished, err)
+
+	// Performing the actions out of order fails the liveness check
+	session, _ = s.Start(userId, voiceit2.Face)
+	result, err = s.Finish(session, sample("ann\n\n"+session.Instructions[1]+" "+session.Instructions[0]))
+	assert.Equal(nil, err)
+	assert.False(result.Passed)
+	assert.Equal("LDFA", result.ResponseCode)
+
+	// Someone else performing the actions fails the biometric match
+	session, _ = s.Start(userId, voiceit2.Face)
+	result, err = s.Finish(session, sample("bob\n\n"+strings.Join(session.Instructions, " ")))
+	assert.Equal(nil, err)
+	assert.False(result.Passed)
+	assert.Equal("FAIL", result.ResponseCode)
+	assert.Equal(40.0, result.FaceConfidence)
+
+	// The challenge of a finished session cannot be used again
+	_, err = s.Finish(&Session{UserId: userId, Modality: voiceit2.Face, LcoId: session.LcoId}, sample("ann\n\n"+strings.Join(session.Instructions, " ")))
+	assert.Contains(err.Error(), "LCNF")
+
+	_, err = s.Start("usr_missing", voiceit2.Voice)
+	assert.Contains(err.Error(), "UNFD")
+	_, err = s.Start(userId, voiceit2.Modality("fingerprint"))
+	assert.Equal(ErrUnknownModality, err)
+}
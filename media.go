@@ -0,0 +1,150 @@
+package voiceit2
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/textproto"
+	"path"
+	"strings"
+)
+
+// MediaType describes a media format recognized from the leading bytes of a file
+type MediaType struct {
+	Kind        string // "audio", "image" or "video"
+	Extension   string // canonical file extension including the dot, e.g. ".wav"
+	ContentType string
+	aliases     []string
+}
+
+var (
+	MediaWAV  = MediaType{Kind: "audio", Extension: ".wav", ContentType: "audio/wav", aliases: []string{".wave"}}
+	MediaMP3  = MediaType{Kind: "audio", Extension: ".mp3", ContentType: "audio/mpeg"}
+	MediaFLAC = MediaType{Kind: "audio", Extension: ".flac", ContentType: "audio/flac"}
+	MediaOGG  = MediaType{Kind: "audio", Extension: ".ogg", ContentType: "audio/ogg", aliases: []string{".oga", ".opus"}}
+	MediaM4A  = MediaType{Kind: "audio", Extension: ".m4a", ContentType: "audio/mp4"}
+	MediaJPEG = MediaType{Kind: "image", Extension: ".jpg", ContentType: "image/jpeg", aliases: []string{".jpeg"}}
+	MediaPNG  = MediaType{Kind: "image", Extension: ".png", ContentType: "image/png"}
+	MediaMP4  = MediaType{Kind: "video", Extension: ".mp4", ContentType: "video/mp4", aliases: []string{".m4v"}}
+	MediaMOV  = MediaType{Kind: "video", Extension: ".mov", ContentType: "video/quicktime"}
+	MediaWebM = MediaType{Kind: "video", Extension: ".webm", ContentType: "video/webm"}
+)
+
+// mediaPartKinds lists the media kinds the API accepts for each multipart file field.
+// Face endpoints upload photos through the "video" field as well
+var mediaPartKinds = map[string][]string{
+	"recording": {"audio"},
+	"photo":     {"image"},
+	"video":     {"video", "image"},
+}
+
+// SniffMediaType inspects the magic bytes at the start of data and returns
+// the detected media type. The boolean is false if the format is not recognized
+func SniffMediaType(data []byte) (MediaType, bool) {
+	switch {
+	case len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WAVE")):
+		return MediaWAV, true
+	case bytes.HasPrefix(data, []byte("fLaC")):
+		return MediaFLAC, true
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return MediaOGG, true
+	case bytes.HasPrefix(data, []byte("ID3")):
+		return MediaMP3, true
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0 && data[1]&0x06 != 0:
+		// MPEG audio frame sync with a non-reserved layer. JPEG (FF D8) does not match the mask
+		return MediaMP3, true
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return MediaJPEG, true
+	case bytes.HasPrefix(data, []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}):
+		return MediaPNG, true
+	case len(data) >= 12 && bytes.Equal(data[4:8], []byte("ftyp")):
+		switch string(data[8:12]) {
+		case "qt  ":
+			return MediaMOV, true
+		case "M4A ", "M4B ":
+			return MediaM4A, true
+		}
+		return MediaMP4, true
+	case bytes.HasPrefix(data, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return MediaWebM, true
+	}
+	return MediaType{}, false
+}
+
+// hasExtension reports whether ext (including the dot) is a valid extension for the media type
+func (m MediaType) hasExtension(ext string) bool {
+	ext = strings.ToLower(ext)
+	if ext == m.Extension {
+		return true
+	}
+	for _, alias := range m.aliases {
+		if ext == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaFileName returns fileName with its extension replaced by the canonical
+// extension for the media type, unless the current one is already valid for it
+func mediaFileName(fileName string, m MediaType) string {
+	ext := path.Ext(fileName)
+	if m.hasExtension(ext) {
+		return fileName
+	}
+	return strings.TrimSuffix(fileName, ext) + m.Extension
+}
+
+// writeMediaPart adds the file contents to the multipart writer under the given field.
+// When the content is recognized, the part's file name extension and Content-Type are
+// corrected to match it. With StrictMediaTypes set, unrecognized content and content
+// of the wrong kind for the field are refused instead of being uploaded as-is
+func (vi VoiceIt2) writeMediaPart(writer *multipart.Writer, field string, filePath string, fileContents []byte) error {
+	fileName := path.Base(filePath)
+	contentType := "application/octet-stream"
+
+	media, ok := SniffMediaType(fileContents)
+	if ok {
+		fileName = mediaFileName(fileName, media)
+		contentType = media.ContentType
+	}
+
+	if vi.StrictMediaTypes {
+		if !ok {
+			return errors.New("unrecognized media format in " + path.Base(filePath))
+		}
+		if !mediaKindAllowed(field, media.Kind) {
+			return errors.New(path.Base(filePath) + " contains " + media.Kind + " (" + media.ContentType + "), which is not accepted for " + field)
+		}
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="`+field+`"; filename="`+escapeQuotes(fileName)+`"`)
+	h.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(fileContents)
+	return err
+}
+
+func mediaKindAllowed(field string, kind string) bool {
+	kinds, ok := mediaPartKinds[field]
+	if !ok {
+		return true
+	}
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes mirrors the escaping mime/multipart applies in CreateFormFile
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
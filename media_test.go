@@ -0,0 +1,93 @@
+package voiceit2
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSniffMediaType(t *testing.T) {
+	assert := assert.New(t)
+
+	samples := map[string][]byte{
+		".wav":  append([]byte("RIFF\x24\x00\x00\x00WAVE"), make([]byte, 8)...),
+		".mp3":  []byte("ID3\x04\x00\x00\x00\x00\x00\x00"),
+		".flac": []byte("fLaC\x00\x00\x00\x22"),
+		".ogg":  []byte("OggS\x00\x02\x00\x00"),
+		".jpg":  {0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10},
+		".png":  {0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A},
+		".mp4":  []byte("\x00\x00\x00\x18ftypisom\x00\x00\x02\x00"),
+		".mov":  []byte("\x00\x00\x00\x14ftypqt  \x00\x00\x02\x00"),
+		".webm": {0x1A, 0x45, 0xDF, 0xA3, 0x9F, 0x42, 0x86, 0x81},
+	}
+	for ext, data := range samples {
+		media, ok := SniffMediaType(data)
+		assert.True(ok, "SniffMediaType() did not recognize "+ext)
+		assert.Equal(ext, media.Extension)
+	}
+
+	// Bare MPEG frame sync without an ID3 tag
+	media, ok := SniffMediaType([]byte{0xFF, 0xFB, 0x90, 0x64})
+	assert.True(ok)
+	assert.Equal(MediaMP3, media)
+
+	_, ok = SniffMediaType([]byte("plain text"))
+	assert.False(ok)
+	_, ok = SniffMediaType(nil)
+	assert.False(ok)
+}
+
+func TestMediaFileName(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("sample.wav", mediaFileName("sample.bin", MediaWAV))
+	assert.Equal("clip.mp4", mediaFileName("clip.mov", MediaMP4))
+	assert.Equal("face.jpeg", mediaFileName("face.jpeg", MediaJPEG))
+	assert.Equal("face.JPG", mediaFileName("face.JPG", MediaJPEG))
+	assert.Equal("recording.wav", mediaFileName("recording", MediaWAV))
+}
+
+func readMediaPart(t *testing.T, vi VoiceIt2, field string, filePath string, data []byte) (*multipart.Part, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := vi.writeMediaPart(writer, field, filePath, data); err != nil {
+		return nil, err
+	}
+	writer.Close()
+	part, err := multipart.NewReader(body, writer.Boundary()).NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return part, nil
+}
+
+func TestWriteMediaPart(t *testing.T) {
+	assert := assert.New(t)
+	wav := append([]byte("RIFF\x24\x00\x00\x00WAVE"), make([]byte, 8)...)
+
+	vi := NewClient("key", "tok")
+	part, err := readMediaPart(t, vi, "recording", "/tmp/sample.bin", wav)
+	assert.Equal(nil, err)
+	assert.Equal("recording", part.FormName())
+	assert.Equal("sample.wav", part.FileName())
+	assert.Equal("audio/wav", part.Header.Get("Content-Type"))
+	contents, _ := ioutil.ReadAll(part)
+	assert.Equal(wav, contents)
+
+	// Unrecognized content is uploaded unchanged unless strict mode is on
+	part, err = readMediaPart(t, vi, "recording", "notes.txt", []byte("hello"))
+	assert.Equal(nil, err)
+	assert.Equal("notes.txt", part.FileName())
+	assert.Equal("application/octet-stream", part.Header.Get("Content-Type"))
+
+	vi.StrictMediaTypes = true
+	_, err = readMediaPart(t, vi, "recording", "notes.txt", []byte("hello"))
+	assert.NotEqual(nil, err, "strict mode should refuse unrecognized content")
+	_, err = readMediaPart(t, vi, "recording", "face.jpg", []byte{0xFF, 0xD8, 0xFF, 0xE0})
+	assert.NotEqual(nil, err, "strict mode should refuse an image as a voice recording")
+	part, err = readMediaPart(t, vi, "video", "face.png", []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A})
+	assert.Equal(nil, err, "photos are accepted through the video field")
+	assert.Equal("image/png", part.Header.Get("Content-Type"))
+}
@@ -0,0 +1,35 @@
+package voiceit2
+
+import "net/http"
+
+// Handler sends a request to the API and returns its response
+type Handler func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps the Handler that sends requests. It sees every request
+// after the credentials and platform headers are set, so it can replace them,
+// and every response before it is read. A middleware may answer a request
+// without calling next, for example from a cache or to inject a fault
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the client's chain. The first middleware added
+// is the outermost, seeing requests first and responses last
+func (vi *VoiceIt2) Use(middleware ...Middleware) {
+	// Copy so clients copied from vi before the call keep their own chain
+	vi.Middleware = append(append([]Middleware(nil), vi.Middleware...), middleware...)
+}
+
+// send sets the credentials and platform headers of req and sends it through
+// the middleware chain
+func (vi VoiceIt2) send(req *http.Request) (*http.Response, error) {
+	if err := vi.setAuth(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("platformId", PlatformId)
+	req.Header.Add("platformVersion", PlatformVersion)
+
+	h := Handler(vi.httpClient().Do)
+	for i := len(vi.Middleware) - 1; i >= 0; i-- {
+		h = vi.Middleware[i](h)
+	}
+	return h(req)
+}
@@ -0,0 +1,65 @@
+package voiceit2
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/fakeapi"
+)
+
+func TestMiddleware(t *testing.T) {
+	assert := assert.New(t)
+	api := fakeapi.New("key", "tok")
+	defer api.Close()
+	userId := api.AddUser()
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, name+" request")
+				resp, err := next(req)
+				order = append(order, name+" response")
+				return resp, err
+			}
+		}
+	}
+	myVoiceIt := NewClient("wrong", "tok")
+	myVoiceIt.BaseUrl = api.URL
+	myVoiceIt.Use(trace("outer"), trace("inner"))
+	// Credentials are set before the chain runs, so middleware can override them
+	myVoiceIt.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			assert.Equal(PlatformId, req.Header.Get("platformId"))
+			req.SetBasicAuth("key", "tok")
+			return next(req)
+		}
+	})
+
+	ret, err := myVoiceIt.CheckUserExists(userId)
+	assert.Equal(nil, err)
+	assert.Contains(string(ret), `"exists":true`)
+	assert.Equal([]string{"outer request", "inner request", "inner response", "outer response"}, order)
+
+	// A middleware can answer without calling the API
+	calls := len(api.Calls())
+	faulty := myVoiceIt
+	faulty.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Method == "DELETE" {
+				return nil, errors.New("injected fault")
+			}
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"responseCode":"SUCC","cached":true}`))}, nil
+		}
+	})
+	_, err = faulty.DeleteUser(userId)
+	assert.Equal("DeleteUser error: injected fault", err.Error())
+	ret, _ = faulty.GetAllUsers()
+	assert.Contains(string(ret), `"cached":true`)
+	assert.Equal(calls, len(api.Calls()))
+	assert.Equal(3, len(myVoiceIt.Middleware), "Use on a copy leaves the original chain alone")
+}
@@ -0,0 +1,16 @@
+package voiceit2
+
+// Modality is the kind of sample a biometric call works on
+type Modality string
+
+// Modalities
+const (
+	Voice Modality = "voice"
+	Face  Modality = "face"
+	Video Modality = "video"
+)
+
+// Valid reports whether m is Voice, Face or Video
+func (m Modality) Valid() bool {
+	return m == Voice || m == Face || m == Video
+}
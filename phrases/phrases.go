@@ -0,0 +1,289 @@
+// Package phrases issues a random challenge phrase for every voice or video
+// verification, so a recording of an earlier verification cannot be replayed
+package phrases
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apiutil"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+var (
+	// ErrUnknownChallenge is returned for challenges that were never issued or were already used
+	ErrUnknownChallenge = errors.New("unknown or already used challenge")
+	// ErrChallengeExpired is returned for challenges used after their expiry
+	ErrChallengeExpired = errors.New("challenge expired")
+	// ErrNoPhrase is returned when the user has no enrollment with a phrase of the account
+	ErrNoPhrase = errors.New("user has no enrolled phrase for the content language")
+)
+
+// PhraseMismatchError is returned when the verified recording did not say the challenge phrase
+type PhraseMismatchError struct {
+	Challenge      string
+	Text           string
+	TextConfidence float64
+}
+
+func (e *PhraseMismatchError) Error() string {
+	return "recording says \"" + e.Text + "\" instead of the challenge \"" + e.Challenge + "\""
+}
+
+// Challenge is a phrase the user must say in one verification attempt
+type Challenge struct {
+	Id              string    `json:"id"`
+	UserId          string    `json:"userId"`
+	ContentLanguage string    `json:"contentLanguage"`
+	Phrase          string    `json:"phrase"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+}
+
+type cachedPhrases struct {
+	phrases   []string
+	fetchedAt time.Time
+}
+
+// Service caches the account's phrases and issues and checks challenges
+type Service struct {
+	Client voiceit2.VoiceIt2
+	// TTL is how long the phrases of a content language are cached. Defaults to an hour
+	TTL time.Duration
+	// ChallengeTTL is how long an issued challenge can be used. Defaults to two minutes
+	ChallengeTTL time.Duration
+	// MinTextConfidence is the lowest TextConfidence accepted for the challenge
+	// phrase. Defaults to 75
+	MinTextConfidence float64
+
+	mu         sync.Mutex
+	phrases    map[string]cachedPhrases
+	challenges map[string]Challenge
+}
+
+// New returns a Service with default settings
+func New(client voiceit2.VoiceIt2) *Service {
+	return &Service{
+		Client:            client,
+		TTL:               time.Hour,
+		ChallengeTTL:      2 * time.Minute,
+		MinTextConfidence: 75,
+		phrases:           map[string]cachedPhrases{},
+		challenges:        map[string]Challenge{},
+	}
+}
+
+// Phrases returns the account's phrases for a content language with GetPhrases,
+// from the cache when it is younger than TTL
+func (s *Service) Phrases(contentLanguage string) ([]string, error) {
+	contentLanguage, err := s.contentLanguage(contentLanguage)
+	if err != nil {
+		return nil, errors.New("Phrases error: " + err.Error())
+	}
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	s.mu.Lock()
+	cached, ok := s.phrases[contentLanguage]
+	s.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < ttl {
+		return cached.phrases, nil
+	}
+
+	ret, err := s.Client.GetPhrases(contentLanguage)
+	var gp structs.GetPhrasesReturn
+	if err := apiutil.Decode("GetPhrases", ret, err, &gp); err != nil {
+		return nil, errors.New("Phrases error: " + err.Error())
+	}
+	list := make([]string, 0, len(gp.Phrases))
+	for _, p := range gp.Phrases {
+		list = append(list, p.Text)
+	}
+	s.mu.Lock()
+	if s.phrases == nil {
+		s.phrases = map[string]cachedPhrases{}
+	}
+	s.phrases[contentLanguage] = cachedPhrases{phrases: list, fetchedAt: time.Now()}
+	s.mu.Unlock()
+	return list, nil
+}
+
+// Issue picks a random phrase among the account's phrases the user has voice
+// enrollments for, found with GetAllVoiceEnrollments, and remembers it as a challenge
+func (s *Service) Issue(userId string, contentLanguage string) (Challenge, error) {
+	contentLanguage, err := s.contentLanguage(contentLanguage)
+	if err != nil {
+		return Challenge{}, errors.New("Issue error: " + err.Error())
+	}
+	account, err := s.Phrases(contentLanguage)
+	if err != nil {
+		return Challenge{}, errors.New("Issue error: " + err.Error())
+	}
+	ret, err := s.Client.GetAllVoiceEnrollments(userId)
+	var gve structs.GetAllVoiceEnrollmentsReturn
+	if err := apiutil.Decode("GetAllVoiceEnrollments", ret, err, &gve); err != nil {
+		return Challenge{}, errors.New("Issue error: " + err.Error())
+	}
+
+	var candidates []string
+	seen := map[string]bool{}
+	for _, e := range gve.VoiceEnrollments {
+		key := normalize(e.Text)
+		if !strings.EqualFold(e.ContentLanguage, contentLanguage) || seen[key] {
+			continue
+		}
+		for _, p := range account {
+			if normalize(p) == key {
+				seen[key] = true
+				candidates = append(candidates, p)
+				break
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return Challenge{}, ErrNoPhrase
+	}
+
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(candidates))))
+	if err != nil {
+		return Challenge{}, errors.New("Issue error: " + err.Error())
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Challenge{}, errors.New("Issue error: " + err.Error())
+	}
+	challengeTTL := s.ChallengeTTL
+	if challengeTTL <= 0 {
+		challengeTTL = 2 * time.Minute
+	}
+	c := Challenge{
+		Id:              hex.EncodeToString(id),
+		UserId:          userId,
+		ContentLanguage: contentLanguage,
+		Phrase:          candidates[i.Int64()],
+		ExpiresAt:       time.Now().Add(challengeTTL),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.challenges == nil {
+		s.challenges = map[string]Challenge{}
+	}
+	// Drop expired challenges so abandoned attempts do not pile up
+	now := time.Now()
+	for id, old := range s.challenges {
+		if now.After(old.ExpiresAt) {
+			delete(s.challenges, id)
+		}
+	}
+	s.challenges[c.Id] = c
+	return c, nil
+}
+
+// contentLanguage resolves a content language as the client does, for example
+// en_us to en-US, so phrases are cached and matched to enrollments once per
+// language. Unlike for the client, one is required
+func (s *Service) contentLanguage(tag string) (string, error) {
+	l, err := s.Client.ContentLanguageFor(tag)
+	if err == nil && l == "" {
+		err = errors.New("empty content language")
+	}
+	return l, err
+}
+
+// take removes and returns an issued challenge. Challenges can only be used once
+func (s *Service) take(challengeId string) (Challenge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.challenges[challengeId]
+	if !ok {
+		return Challenge{}, ErrUnknownChallenge
+	}
+	delete(s.challenges, challengeId)
+	if time.Now().After(c.ExpiresAt) {
+		return Challenge{}, ErrChallengeExpired
+	}
+	return c, nil
+}
+
+// Check uses up a challenge and checks the reply of the VoiceVerification or
+// VideoVerification call made for it. It fails unless the verification
+// succeeded and the recording said the challenge phrase
+func (s *Service) Check(challengeId string, ret []byte) error {
+	c, err := s.take(challengeId)
+	if err != nil {
+		return err
+	}
+	return s.check(c, ret)
+}
+
+func (s *Service) check(c Challenge, ret []byte) error {
+	var vr struct {
+		Text           string  `json:"text"`
+		TextConfidence float64 `json:"textConfidence"`
+	}
+	if err := apiutil.Decode("Verification", ret, nil, &vr); err != nil {
+		return err
+	}
+	minConfidence := s.MinTextConfidence
+	if minConfidence <= 0 {
+		minConfidence = 75
+	}
+	if normalize(vr.Text) != normalize(c.Phrase) || vr.TextConfidence < minConfidence {
+		return &PhraseMismatchError{Challenge: c.Phrase, Text: vr.Text, TextConfidence: vr.TextConfidence}
+	}
+	return nil
+}
+
+// VerifyVoice runs VoiceVerification for the challenge's user and phrase with the
+// recording at filePath and checks the reply with Check. The reply is returned
+// even when the check fails
+func (s *Service) VerifyVoice(challengeId string, filePath string) ([]byte, error) {
+	c, err := s.take(challengeId)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := s.Client.VoiceVerification(c.UserId, c.ContentLanguage, c.Phrase, filePath)
+	if err != nil {
+		return nil, errors.New("VerifyVoice error: " + err.Error())
+	}
+	return ret, s.check(c, ret)
+}
+
+// VerifyVideo is VerifyVoice for VideoVerification
+func (s *Service) VerifyVideo(challengeId string, filePath string) ([]byte, error) {
+	c, err := s.take(challengeId)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := s.Client.VideoVerification(c.UserId, c.ContentLanguage, c.Phrase, filePath)
+	if err != nil {
+		return nil, errors.New("VerifyVideo error: " + err.Error())
+	}
+	return ret, s.check(c, ret)
+}
+
+// normalize makes phrases comparable regardless of case, punctuation and spacing
+func normalize(phrase string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(phrase) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127:
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,89 @@
+package phrases
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apitest"
+)
+
+func TestChallenges(t *testing.T) {
+	assert := assert.New(t)
+	api, myVoiceIt := apitest.New(t)
+	dir, _ := ioutil.TempDir("", "phrases")
+	defer os.RemoveAll(dir)
+	sample := func(content string) string {
+		f, _ := ioutil.TempFile(dir, "sample")
+		f.WriteString(content)
+		f.Close()
+		return f.Name()
+	}
+
+	userId := api.AddUser()
+	enrolled := []string{"never forget tomorrow is a new day", "today is a nice day to go for a walk"}
+	for _, phrase := range enrolled {
+		_, err := myVoiceIt.CreateVoiceEnrollment(userId, "en-US", phrase, sample("ann\n"+phrase))
+		assert.Equal(nil, err)
+	}
+	// Enrolled with a phrase the account no longer has
+	myVoiceIt.CreateVoiceEnrollment(userId, "en-US", "retired phrase", sample("ann\nretired phrase"))
+
+	s := New(myVoiceIt)
+	issued := map[string]bool{}
+	for i := 0; i < 30; i++ {
+		c, err := s.Issue(userId, "en-US")
+		assert.Equal(nil, err)
+		assert.Contains(enrolled, c.Phrase)
+		issued[c.Phrase] = true
+	}
+	assert.Equal(2, len(issued), "both enrolled phrases are used")
+	gets := 0
+	for _, call := range api.Calls() {
+		if call == "GET /phrases/en-US" {
+			gets++
+		}
+	}
+	assert.Equal(1, gets, "phrases are cached")
+
+	// Content languages are normalized before phrases are cached and matched
+	c, err := s.Issue(userId, "en_us")
+	assert.Equal(nil, err)
+	assert.Equal("en-US", c.ContentLanguage)
+	assert.Contains(enrolled, c.Phrase)
+	assert.Equal(1, len(s.phrases), "en_us shares the en-US cache entry")
+	_, err = s.Issue(userId, "xx-YY")
+	assert.Contains(err.Error(), "unsupported content language")
+
+	c, _ = s.Issue(userId, "en-US")
+	ret, err := s.VerifyVoice(c.Id, sample("ann\n"+c.Phrase))
+	assert.Equal(nil, err)
+	assert.Contains(string(ret), `"responseCode":"SUCC"`)
+	_, err = s.VerifyVoice(c.Id, sample("ann\n"+c.Phrase))
+	assert.Equal(ErrUnknownChallenge, err, "challenges are single use")
+
+	// A replayed recording of the other phrase passes voice verification but not the challenge
+	c, _ = s.Issue(userId, "en-US")
+	other := enrolled[0]
+	if other == c.Phrase {
+		other = enrolled[1]
+	}
+	_, err = s.VerifyVoice(c.Id, sample("ann\n"+other))
+	mismatch, ok := err.(*PhraseMismatchError)
+	assert.True(ok, "%v", err)
+	assert.Equal(other, mismatch.Text)
+
+	s.ChallengeTTL = time.Nanosecond
+	c, _ = s.Issue(userId, "en-US")
+	time.Sleep(time.Millisecond)
+	assert.Equal(ErrChallengeExpired, s.Check(c.Id, ret))
+
+	_, err = s.Issue(api.AddUser(), "en-US")
+	assert.Equal(ErrNoPhrase, err)
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "never forget tomorrow is a new day", normalize("  Never forget, tomorrow is a new day! "))
+}
@@ -0,0 +1,187 @@
+// Package preflight checks recordings locally before they are sent to the API,
+// so obviously unusable samples can be rejected with a useful hint
+package preflight
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+)
+
+// Thresholds used for hints
+const (
+	MinSampleRate = 16000
+	MinDuration   = 1500 * time.Millisecond
+	MaxDuration   = 10 * time.Second
+	// MinLevel is the lowest RMS level, in dBFS, of a usable recording
+	MinLevel = -40.0
+	// MaxClipping is the highest share of clipped samples of a usable recording
+	MaxClipping = 0.01
+)
+
+// Report is the result of analyzing a recording
+type Report struct {
+	// Format is the detected media type, or "unknown"
+	Format string
+	// Analyzed is false when the format cannot be analyzed locally. Only PCM and
+	// float WAV files are; other formats only get their type detected
+	Analyzed      bool
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+	Duration      time.Duration
+	// Level is the RMS level in dBFS, -Inf for digital silence
+	Level float64
+	// Peak is the highest absolute sample value in dBFS
+	Peak float64
+	// Clipping is the share of samples at full scale
+	Clipping float64
+	// Hints are actionable problems found in the recording
+	Hints []string
+}
+
+// OK reports whether no problem was found
+func (r Report) OK() bool {
+	return len(r.Hints) == 0
+}
+
+// Analyze inspects a recording. It never fails on unknown formats, whose report
+// only says they could not be analyzed
+func Analyze(data []byte) Report {
+	r := Report{Format: "unknown", Level: math.Inf(-1), Peak: math.Inf(-1)}
+	mt, ok := voiceit2.SniffMediaType(data)
+	if !ok {
+		r.Hints = append(r.Hints, "file is not a recognized media format")
+		return r
+	}
+	r.Format = mt.Extension[1:]
+	if mt.Kind != "audio" {
+		r.Hints = append(r.Hints, "file is "+mt.Kind+", not audio")
+		return r
+	}
+	if mt.Extension != voiceit2.MediaWAV.Extension {
+		return r
+	}
+	if err := analyzeWAV(data, &r); err != nil {
+		r.Hints = append(r.Hints, "WAV file cannot be read: "+err.Error())
+		return r
+	}
+	r.Analyzed = true
+
+	switch {
+	case r.Duration < MinDuration:
+		r.Hints = append(r.Hints, "recording is "+r.Duration.String()+" long; record the whole phrase, at least "+MinDuration.String())
+	case r.Duration > MaxDuration:
+		r.Hints = append(r.Hints, "recording is "+r.Duration.String()+" long; trim silence to stay under "+MaxDuration.String())
+	}
+	if r.SampleRate < MinSampleRate {
+		r.Hints = append(r.Hints, "sample rate is "+strconv.Itoa(r.SampleRate)+" Hz; record at "+strconv.Itoa(MinSampleRate)+" Hz or more")
+	}
+	if math.IsInf(r.Level, -1) {
+		r.Hints = append(r.Hints, "recording is silent; check the microphone")
+	} else if r.Level < MinLevel {
+		r.Hints = append(r.Hints, "recording is very quiet ("+strconv.FormatFloat(r.Level, 'f', 1, 64)+" dBFS); speak closer to the microphone or raise the gain")
+	}
+	if r.Clipping > MaxClipping {
+		r.Hints = append(r.Hints, "recording is clipped ("+strconv.FormatFloat(r.Clipping*100, 'f', 1, 64)+"% of samples); lower the gain")
+	}
+	return r
+}
+
+// analyzeWAV reads the fmt and data chunks of a RIFF WAVE file
+func analyzeWAV(data []byte, r *Report) error {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return errors.New("missing RIFF header")
+	}
+	var format uint16
+	var samples []byte
+	haveFmt := false
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := data[pos+8:]
+		if size > len(body) {
+			// Streaming recorders leave the size unset; use what is there
+			size = len(body)
+		}
+		body = body[:size]
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return errors.New("fmt chunk too short")
+			}
+			format = binary.LittleEndian.Uint16(body[0:2])
+			r.Channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			r.SampleRate = int(binary.LittleEndian.Uint32(body[4:8]))
+			r.BitsPerSample = int(binary.LittleEndian.Uint16(body[14:16]))
+			if format == 0xFFFE && size >= 26 {
+				// WAVE_FORMAT_EXTENSIBLE keeps the actual format in the sub-format GUID
+				format = binary.LittleEndian.Uint16(body[24:26])
+			}
+			haveFmt = true
+		case "data":
+			samples = body
+		}
+		pos += 8 + size + size%2
+	}
+	if !haveFmt || samples == nil {
+		return errors.New("missing fmt or data chunk")
+	}
+	if r.Channels == 0 || r.SampleRate == 0 {
+		return errors.New("invalid fmt chunk")
+	}
+
+	width := r.BitsPerSample / 8
+	var sample func(b []byte) float64
+	switch {
+	case format == 1 && width == 1:
+		sample = func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }
+	case format == 1 && width == 2:
+		sample = func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / 32768 }
+	case format == 1 && width == 3:
+		sample = func(b []byte) float64 {
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+			return float64(v) / 8388608
+		}
+	case format == 1 && width == 4:
+		sample = func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648 }
+	case format == 3 && width == 4:
+		sample = func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }
+	default:
+		return errors.New("unsupported encoding " + strconv.Itoa(int(format)) + " with " + strconv.Itoa(r.BitsPerSample) + " bits per sample")
+	}
+
+	n := len(samples) / width
+	frames := n / r.Channels
+	r.Duration = time.Duration(frames) * time.Second / time.Duration(r.SampleRate)
+	if n == 0 {
+		return nil
+	}
+	var sum, peak float64
+	clipped := 0
+	for i := 0; i < n; i++ {
+		v := math.Abs(sample(samples[i*width : (i+1)*width]))
+		sum += v * v
+		if v > peak {
+			peak = v
+		}
+		if v >= 0.999 {
+			clipped++
+		}
+	}
+	r.Level = decibels(math.Sqrt(sum / float64(n)))
+	r.Peak = decibels(peak)
+	r.Clipping = float64(clipped) / float64(n)
+	return nil
+}
+
+func decibels(v float64) float64 {
+	if v == 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(v)
+}
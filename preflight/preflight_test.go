@@ -0,0 +1,60 @@
+package preflight
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// wav returns a mono 16-bit PCM WAV file of a sine wave
+func wav(sampleRate int, duration time.Duration, amplitude float64) []byte {
+	n := int(duration * time.Duration(sampleRate) / time.Second)
+	data := make([]byte, 44+2*n)
+	copy(data[0:], "RIFF")
+	binary.LittleEndian.PutUint32(data[4:], uint32(36+2*n))
+	copy(data[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(data[16:], 16)
+	binary.LittleEndian.PutUint16(data[20:], 1)
+	binary.LittleEndian.PutUint16(data[22:], 1)
+	binary.LittleEndian.PutUint32(data[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(data[28:], uint32(2*sampleRate))
+	binary.LittleEndian.PutUint16(data[32:], 2)
+	binary.LittleEndian.PutUint16(data[34:], 16)
+	copy(data[36:], "data")
+	binary.LittleEndian.PutUint32(data[40:], uint32(2*n))
+	for i := 0; i < n; i++ {
+		v := amplitude * math.Sin(2*math.Pi*440*float64(i)/float64(sampleRate))
+		s := int16(math.Max(-32768, math.Min(32767, v*32768)))
+		binary.LittleEndian.PutUint16(data[44+2*i:], uint16(s))
+	}
+	return data
+}
+
+func TestAnalyze(t *testing.T) {
+	assert := assert.New(t)
+
+	r := Analyze(wav(16000, 3*time.Second, 0.5))
+	assert.True(r.Analyzed)
+	assert.True(r.OK(), "%v", r.Hints)
+	assert.Equal("wav", r.Format)
+	assert.Equal(3*time.Second, r.Duration)
+	assert.InDelta(-9.0, r.Level, 0.2, "RMS of a half-scale sine")
+	assert.InDelta(-6.0, r.Peak, 0.2)
+
+	hints := func(r Report) string { return strings.Join(r.Hints, "\n") }
+	assert.Contains(hints(Analyze(wav(8000, 3*time.Second, 0.5))), "sample rate is 8000 Hz")
+	assert.Contains(hints(Analyze(wav(16000, 500*time.Millisecond, 0.5))), "at least")
+	assert.Contains(hints(Analyze(wav(16000, 3*time.Second, 0.001))), "very quiet")
+	assert.Contains(hints(Analyze(wav(16000, 3*time.Second, 0))), "silent")
+	assert.Contains(hints(Analyze(wav(16000, 3*time.Second, 4))), "clipped")
+
+	r = Analyze([]byte("ID3\x03\x00\x00\x00\x00\x00\x00 mp3"))
+	assert.False(r.Analyzed)
+	assert.True(r.OK())
+	assert.Contains(hints(Analyze([]byte("plain text"))), "not a recognized media format")
+	assert.Contains(hints(Analyze([]byte("RIFF\x04\x00\x00\x00WAVE"))), "cannot be read")
+}
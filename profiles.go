@@ -0,0 +1,171 @@
+package voiceit2
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/store"
+)
+
+// Profile is a named set of credentials for an account or sub-account
+type Profile struct {
+	APIKey   string
+	APIToken string
+	// BaseUrl overrides the default API base URL when set
+	BaseUrl string
+	// LivenessUrl overrides the default liveness base URL when set. If it is
+	// empty and BaseUrl is set, liveness calls go to BaseUrl, so that a profile
+	// for another environment never sends them to the production liveness service
+	LivenessUrl string
+}
+
+// Config holds named profiles. It is stored as an INI file with one section per
+// profile and the name of the default profile at the top:
+//
+//	default = master
+//
+//	[master]
+//	apiKey = key_...
+//	apiToken = tok_...
+//	baseUrl = https://api.voiceit.io
+//	livenessUrl = https://liveness.voiceit.io
+type Config struct {
+	// Default is the profile used when none is named. If empty, the profile
+	// named "default" is used
+	Default  string
+	Profiles map[string]Profile
+}
+
+// DefaultConfigPath returns ~/.config/voiceit2/config, or the same file under
+// $XDG_CONFIG_HOME when it is set
+func DefaultConfigPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "voiceit2", "config"), nil
+}
+
+// LoadConfig reads the config file at path. A missing file is an empty config
+func LoadConfig(path string) (*Config, error) {
+	c := &Config{Profiles: map[string]Profile{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, errors.New("LoadConfig error: " + err.Error())
+	}
+	for name, entries := range parseINI(data) {
+		if name == "" {
+			c.Default = entries["default"]
+			continue
+		}
+		c.Profiles[name] = Profile{APIKey: entries["apikey"], APIToken: entries["apitoken"], BaseUrl: entries["baseurl"], LivenessUrl: entries["livenessurl"]}
+	}
+	return c, nil
+}
+
+// Save writes the config to path, readable by the owner only
+func (c *Config) Save(path string) error {
+	var b bytes.Buffer
+	if c.Default != "" {
+		b.WriteString("default = " + c.Default + "\n")
+	}
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := c.Profiles[name]
+		b.WriteString("\n[" + name + "]\n")
+		b.WriteString("apiKey = " + p.APIKey + "\n")
+		b.WriteString("apiToken = " + p.APIToken + "\n")
+		if p.BaseUrl != "" {
+			b.WriteString("baseUrl = " + p.BaseUrl + "\n")
+		}
+		if p.LivenessUrl != "" {
+			b.WriteString("livenessUrl = " + p.LivenessUrl + "\n")
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.New("Save error: " + err.Error())
+	}
+	if err := store.WriteFile(path, b.Bytes(), 0600); err != nil {
+		return errors.New("Save error: " + err.Error())
+	}
+	return nil
+}
+
+// Profile returns the named profile, or the default profile if name is empty
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" {
+		name = "default"
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, errors.New("profile " + name + " not found")
+	}
+	return p, nil
+}
+
+// Set adds or replaces a profile. Names may not contain brackets or line breaks
+func (c *Config) Set(name string, p Profile) error {
+	if name == "" || strings.ContainsAny(name, "[]\r\n") {
+		return errors.New("invalid profile name \"" + name + "\"")
+	}
+	if c.Profiles == nil {
+		c.Profiles = map[string]Profile{}
+	}
+	c.Profiles[name] = p
+	return nil
+}
+
+// Client returns a client for the named profile, or for the default profile if name is empty
+func (c *Config) Client(name string) (VoiceIt2, error) {
+	p, err := c.Profile(name)
+	if err != nil {
+		return VoiceIt2{}, err
+	}
+	vi := NewClient(p.APIKey, p.APIToken)
+	if p.BaseUrl != "" {
+		vi.BaseUrl = p.BaseUrl
+		vi.LivenessUrl = p.BaseUrl
+	}
+	if p.LivenessUrl != "" {
+		vi.LivenessUrl = p.LivenessUrl
+	}
+	return vi, nil
+}
+
+// NewClientFromProfile returns a client for a profile of the config file at
+// DefaultConfigPath, or for its default profile if name is empty
+func NewClientFromProfile(name string) (VoiceIt2, error) {
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return VoiceIt2{}, errors.New("NewClientFromProfile error: " + err.Error())
+	}
+	c, err := LoadConfig(path)
+	if err != nil {
+		return VoiceIt2{}, errors.New("NewClientFromProfile error: " + err.Error())
+	}
+	vi, err := c.Client(name)
+	if err != nil {
+		return VoiceIt2{}, errors.New("NewClientFromProfile error: " + err.Error())
+	}
+	return vi, nil
+}
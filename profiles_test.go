@@ -0,0 +1,57 @@
+package voiceit2
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "voiceit2", "config")
+
+	c, err := LoadConfig(path)
+	assert.Equal(nil, err)
+	assert.Equal(0, len(c.Profiles))
+	assert.Equal(nil, c.Set("master", Profile{APIKey: "key_master", APIToken: "tok_master"}))
+	assert.Equal(nil, c.Set("acme", Profile{APIKey: "key_acme", APIToken: "tok_acme", BaseUrl: "https://eu.example"}))
+	assert.Equal(nil, c.Set("staging", Profile{APIKey: "key_staging", APIToken: "tok_staging", BaseUrl: "https://api.staging.example", LivenessUrl: "https://liveness.staging.example"}))
+	assert.NotEqual(nil, c.Set("bad]name", Profile{}))
+	c.Default = "master"
+	assert.Equal(nil, c.Save(path))
+
+	info, _ := os.Stat(path)
+	assert.Equal(os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := LoadConfig(path)
+	assert.Equal(nil, err)
+	assert.Equal(c, loaded)
+	vi, err := loaded.Client("")
+	assert.Equal(nil, err)
+	assert.Equal("key_master", vi.APIKey)
+	assert.Equal("https://api.voiceit.io", vi.BaseUrl)
+	assert.Equal("https://liveness.voiceit.io", vi.LivenessUrl)
+	vi, _ = loaded.Client("acme")
+	assert.Equal("https://eu.example", vi.BaseUrl)
+	assert.Equal("https://eu.example", vi.LivenessUrl, "liveness calls follow the base URL")
+	vi, _ = loaded.Client("staging")
+	assert.Equal("https://liveness.staging.example", vi.LivenessUrl)
+	_, err = loaded.Client("missing")
+	assert.NotEqual(nil, err)
+
+	// A profile section is also a credentials file
+	creds, err := FileCredentials{Path: path, Profile: "acme"}.Credentials()
+	assert.Equal(nil, err)
+	assert.Equal("tok_acme", creds.APIToken)
+
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	vi, err = NewClientFromProfile("acme")
+	assert.Equal(nil, err)
+	assert.Equal("key_acme", vi.APIKey)
+}
@@ -0,0 +1,313 @@
+// Package reconcile keeps VoiceIt groups in sync with group membership kept elsewhere,
+// such as an authorization directory. Groups are matched by their description
+package reconcile
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apiutil"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+// DesiredState maps group descriptions to the userIds that should be members.
+// Groups whose description is not a key are left untouched
+type DesiredState map[string][]string
+
+// Kinds of plan actions
+const (
+	CreateGroup = "createGroup"
+	AddUser     = "addUser"
+	RemoveUser  = "removeUser"
+)
+
+// Action is one API call of a plan. GroupId is empty for groups that do not exist yet
+type Action struct {
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+	GroupId     string `json:"groupId,omitempty"`
+	UserId      string `json:"userId,omitempty"`
+}
+
+func (a Action) String() string {
+	target := a.GroupId
+	if target == "" {
+		target = "(new)"
+	}
+	switch a.Kind {
+	case CreateGroup:
+		return "create group \"" + a.Description + "\""
+	case AddUser:
+		return "add " + a.UserId + " to \"" + a.Description + "\" " + target
+	}
+	return "remove " + a.UserId + " from \"" + a.Description + "\" " + target
+}
+
+// Plan is the ordered list of actions that turns the actual state into the desired state
+type Plan struct {
+	Actions []Action `json:"actions"`
+}
+
+// Empty reports whether the actual state already matches the desired state
+func (p Plan) Empty() bool {
+	return len(p.Actions) == 0
+}
+
+func (p Plan) String() string {
+	lines := make([]string, len(p.Actions))
+	for i, a := range p.Actions {
+		lines[i] = a.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ActionResult is the outcome of applying one action
+type ActionResult struct {
+	Action
+	Attempts int
+	Err      error
+}
+
+// Reconciler computes and applies plans with the client
+type Reconciler struct {
+	Client voiceit2.VoiceIt2
+	// DryRun makes Reconcile return the plan without applying it
+	DryRun bool
+	// Retries is how many times a failed action is retried when the failure is
+	// temporary (transport errors and server side errors). Defaults to 3
+	Retries int
+	// Backoff is the wait before the first retry; it doubles on every retry. Defaults to one second
+	Backoff time.Duration
+}
+
+// New returns a Reconciler for the client with default settings
+func New(client voiceit2.VoiceIt2) *Reconciler {
+	return &Reconciler{Client: client, Retries: 3, Backoff: time.Second}
+}
+
+// actualGroups returns the existing groups keyed by description. It fails if a
+// description from the desired state is shared by several groups, since the
+// group to reconcile would then be ambiguous
+func (r *Reconciler) actualGroups(desired DesiredState) (map[string]structs.GetGroupReturn, error) {
+	ret, err := r.Client.GetAllGroups()
+	var gag structs.GetAllGroupsReturn
+	if err := apiutil.Decode("GetAllGroups", ret, err, &gag); err != nil {
+		return nil, err
+	}
+
+	groups := map[string]structs.GetGroupReturn{}
+	for _, g := range gag.Groups {
+		if _, managed := desired[g.Description]; !managed {
+			continue
+		}
+		if existing, ok := groups[g.Description]; ok {
+			return nil, errors.New("groups " + existing.GroupId + " and " + g.GroupId + " share the description \"" + g.Description + "\"")
+		}
+		ret, err := r.Client.GetGroup(g.GroupId)
+		var gg structs.GetGroupReturn
+		if err := apiutil.Decode("GetGroup", ret, err, &gg); err != nil {
+			return nil, err
+		}
+		gg.GroupId = g.GroupId
+		gg.Description = g.Description
+		groups[g.Description] = gg
+	}
+	return groups, nil
+}
+
+// Plan reads the actual state and computes the actions needed to reach the desired state
+func (r *Reconciler) Plan(desired DesiredState) (Plan, error) {
+	actual, err := r.actualGroups(desired)
+	if err != nil {
+		return Plan{}, errors.New("Plan error: " + err.Error())
+	}
+
+	descriptions := make([]string, 0, len(desired))
+	for description := range desired {
+		descriptions = append(descriptions, description)
+	}
+	sort.Strings(descriptions)
+
+	plan := Plan{Actions: []Action{}}
+	for _, description := range descriptions {
+		want := unique(desired[description])
+		group, exists := actual[description]
+		if !exists {
+			plan.Actions = append(plan.Actions, Action{Kind: CreateGroup, Description: description})
+		}
+		have := map[string]bool{}
+		for _, userId := range group.Users {
+			have[userId] = true
+		}
+		for _, userId := range want {
+			if !have[userId] {
+				plan.Actions = append(plan.Actions, Action{Kind: AddUser, Description: description, GroupId: group.GroupId, UserId: userId})
+			}
+			delete(have, userId)
+		}
+		extra := make([]string, 0, len(have))
+		for userId := range have {
+			extra = append(extra, userId)
+		}
+		sort.Strings(extra)
+		for _, userId := range extra {
+			plan.Actions = append(plan.Actions, Action{Kind: RemoveUser, Description: description, GroupId: group.GroupId, UserId: userId})
+		}
+	}
+	return plan, nil
+}
+
+// Apply runs the plan's actions in order. Actions for a group whose creation
+// failed are skipped. The returned error summarizes the failed actions
+func (r *Reconciler) Apply(plan Plan) ([]ActionResult, error) {
+	created := map[string]string{}
+	failedGroups := map[string]bool{}
+	results := make([]ActionResult, 0, len(plan.Actions))
+	failures := 0
+
+	for _, action := range plan.Actions {
+		res := ActionResult{Action: action}
+		if res.GroupId == "" {
+			res.GroupId = created[res.Description]
+		}
+		if failedGroups[res.Description] {
+			res.Err = errors.New("group \"" + res.Description + "\" could not be created")
+		} else {
+			r.applyWithRetries(&res)
+			if res.Err == nil && res.Kind == CreateGroup {
+				created[res.Description] = res.GroupId
+			}
+			if res.Err != nil && res.Kind == CreateGroup {
+				failedGroups[res.Description] = true
+			}
+		}
+		if res.Err != nil {
+			failures++
+		}
+		results = append(results, res)
+	}
+
+	if failures > 0 {
+		messages := []string{}
+		for _, res := range results {
+			if res.Err != nil {
+				messages = append(messages, res.Action.String()+": "+res.Err.Error())
+			}
+		}
+		return results, errors.New("Apply error: " + strings.Join(messages, "; "))
+	}
+	return results, nil
+}
+
+// Reconcile computes the plan for the desired state and applies it unless DryRun is set
+func (r *Reconciler) Reconcile(desired DesiredState) (Plan, []ActionResult, error) {
+	plan, err := r.Plan(desired)
+	if err != nil || r.DryRun {
+		return plan, nil, err
+	}
+	results, err := r.Apply(plan)
+	return plan, results, err
+}
+
+func (r *Reconciler) applyWithRetries(res *ActionResult) {
+	backoff := r.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	retries := r.Retries
+	if retries < 0 {
+		retries = 0
+	}
+	for {
+		res.Attempts++
+		res.Err = r.apply(res)
+		if res.Err == nil || res.Attempts > retries || !temporary(res.Err) {
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// apply performs a single attempt of an action. Before a retried attempt, the
+// current state is checked first so an attempt whose reply was lost is not repeated
+func (r *Reconciler) apply(res *ActionResult) error {
+	if res.Attempts > 1 {
+		done, err := r.alreadyApplied(res)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+
+	switch res.Kind {
+	case CreateGroup:
+		ret, err := r.Client.CreateGroup(res.Description)
+		var cg structs.CreateGroupReturn
+		if err := apiutil.Decode("CreateGroup", ret, err, &cg); err != nil {
+			return err
+		}
+		res.GroupId = cg.GroupId
+		return nil
+	case AddUser:
+		ret, err := r.Client.AddUserToGroup(res.GroupId, res.UserId)
+		return apiutil.Decode("AddUserToGroup", ret, err, nil)
+	case RemoveUser:
+		ret, err := r.Client.RemoveUserFromGroup(res.GroupId, res.UserId)
+		return apiutil.Decode("RemoveUserFromGroup", ret, err, nil)
+	}
+	return errors.New("unknown action kind \"" + res.Kind + "\"")
+}
+
+func (r *Reconciler) alreadyApplied(res *ActionResult) (bool, error) {
+	if res.Kind == CreateGroup {
+		actual, err := r.actualGroups(DesiredState{res.Description: nil})
+		if err != nil {
+			return false, err
+		}
+		group, ok := actual[res.Description]
+		if ok {
+			res.GroupId = group.GroupId
+		}
+		return ok, nil
+	}
+
+	ret, err := r.Client.GetGroup(res.GroupId)
+	var gg structs.GetGroupReturn
+	if err := apiutil.Decode("GetGroup", ret, err, &gg); err != nil {
+		return false, err
+	}
+	member := false
+	for _, userId := range gg.Users {
+		if userId == res.UserId {
+			member = true
+		}
+	}
+	return member == (res.Kind == AddUser), nil
+}
+
+// temporary reports whether a failed attempt is worth retrying. Errors that
+// are not API errors come from the transport
+func temporary(err error) bool {
+	if apiErr, ok := err.(*apiutil.APIError); ok {
+		return apiErr.Temporary()
+	}
+	return true
+}
+
+func unique(userIds []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, userId := range userIds {
+		if !seen[userId] {
+			seen[userId] = true
+			out = append(out, userId)
+		}
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apitest"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+func members(t *testing.T, client voiceit2.VoiceIt2, groupId string) []string {
+	ret, _ := client.GetGroup(groupId)
+	var gg structs.GetGroupReturn
+	if err := json.Unmarshal(ret, &gg); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(gg.Users)
+	return gg.Users
+}
+
+func TestReconcile(t *testing.T) {
+	assert := assert.New(t)
+	api, client := apitest.New(t)
+
+	alice, bob, carol := api.AddUser(), api.AddUser(), api.AddUser()
+	admins := api.AddGroup("admins", alice, carol)
+	unmanaged := api.AddGroup("unmanaged", bob)
+
+	r := New(client)
+	r.Backoff = time.Millisecond
+	desired := DesiredState{
+		"admins":   {alice, bob},
+		"auditors": {carol, carol},
+	}
+
+	r.DryRun = true
+	plan, results, err := r.Reconcile(desired)
+	assert.Equal(nil, err)
+	assert.Equal(0, len(results))
+	assert.Equal([]Action{
+		{Kind: AddUser, Description: "admins", GroupId: admins, UserId: bob},
+		{Kind: RemoveUser, Description: "admins", GroupId: admins, UserId: carol},
+		{Kind: CreateGroup, Description: "auditors"},
+		{Kind: AddUser, Description: "auditors", UserId: carol},
+	}, plan.Actions)
+	assert.Equal([]string{alice, carol}, members(t, client, admins), "dry run must not change anything")
+
+	// A lost reply on group creation must not create the group twice
+	api.FailAfter("POST /groups", 1, 502)
+	api.Fail("PUT /groups/addUser", 1, 503)
+	r.DryRun = false
+	_, results, err = r.Reconcile(desired)
+	assert.Equal(nil, err)
+	assert.Equal(4, len(results))
+	assert.Equal(2, results[0].Attempts)
+	assert.Equal(2, results[2].Attempts)
+	assert.Equal([]string{alice, bob}, members(t, client, admins))
+	assert.Equal([]string{carol}, members(t, client, results[3].GroupId))
+	assert.Equal([]string{bob}, members(t, client, unmanaged))
+
+	plan, err = r.Plan(desired)
+	assert.Equal(nil, err)
+	assert.True(plan.Empty(), plan.String())
+
+	ret, _ := client.GetAllGroups()
+	var gag structs.GetAllGroupsReturn
+	json.Unmarshal(ret, &gag)
+	assert.Equal(3, len(gag.Groups))
+}
+
+func TestApplyReportsPermanentFailures(t *testing.T) {
+	assert := assert.New(t)
+	api, client := apitest.New(t)
+	api.AddGroup("admins")
+
+	r := New(client)
+	_, results, err := r.Reconcile(DesiredState{"admins": {"usr_missing"}})
+	assert.NotEqual(nil, err)
+	assert.Equal(1, results[0].Attempts, "a missing user is not retried")
+}
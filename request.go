@@ -0,0 +1,112 @@
+package voiceit2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// NewRequest builds a request for an API endpoint the client has no method
+// for. path is relative to BaseUrl and may include a query; the client's
+// notification URL is added to it. fields are sent as multipart form fields and
+// files, mapping a field to a file path, as uploads whose media type is
+// checked like the client methods do. Without fields or files the request has
+// no body. Credentials and platform headers are set when the request is sent
+// with Do
+func (vi VoiceIt2) NewRequest(ctx context.Context, method, path string, fields map[string]string, files map[string]string) (*http.Request, error) {
+	var body io.Reader
+	contentType := ""
+	if len(fields) > 0 || len(files) > 0 {
+		buf := &bytes.Buffer{}
+		writer := multipart.NewWriter(buf)
+		for _, field := range sortedKeys(files) {
+			contents, err := ioutil.ReadFile(files[field])
+			if err != nil {
+				return nil, errors.New("NewRequest error: " + err.Error())
+			}
+			if err := vi.writeMediaPart(writer, field, files[field], contents); err != nil {
+				return nil, errors.New("NewRequest error: " + err.Error())
+			}
+		}
+		for _, field := range sortedKeys(fields) {
+			if err := writer.WriteField(field, fields[field]); err != nil {
+				return nil, errors.New("NewRequest error: " + err.Error())
+			}
+		}
+		writer.Close()
+		body, contentType = buf, writer.FormDataContentType()
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	req, err := http.NewRequest(method, vi.withNotificationUrl(vi.BaseUrl+path), body)
+	if err != nil {
+		return nil, errors.New("NewRequest error: " + err.Error())
+	}
+	if contentType != "" {
+		req.Header.Add("Content-Type", contentType)
+	}
+	return req.WithContext(ctx), nil
+}
+
+// NewEndpointRequest builds a request for an endpoint, as listed by Endpoints,
+// from its parameters. Unlike NewRequest it is sent to the endpoint's own host,
+// LivenessUrl for the liveness endpoints and BaseUrl otherwise, and the content
+// language parameter is resolved as the client methods do. Requests sent with
+// Do are not written to the AuditSink; use the client methods or Do[T] for
+// that
+func (vi VoiceIt2) NewEndpointRequest(ctx context.Context, e Endpoint, p Params) (*http.Request, error) {
+	params := Params{}
+	for name, value := range p {
+		params[name] = value
+	}
+	if e.Language {
+		contentLanguage, err := vi.ContentLanguageFor(params["contentLanguage"])
+		if err != nil {
+			return nil, errors.New("NewEndpointRequest error: " + err.Error())
+		}
+		params["contentLanguage"] = contentLanguage
+	}
+	req, _, err := vi.newEndpointRequest(e, params)
+	if err != nil {
+		return nil, errors.New("NewEndpointRequest error: " + err.Error())
+	}
+	return req.WithContext(ctx), nil
+}
+
+// Do sends a request made with NewRequest or NewEndpointRequest, or any request to the API, with the
+// client's credentials and platform headers through its middleware chain. If
+// out is not nil the reply is decoded into it. As with the client methods, a
+// reply is not an error whatever its HTTP status; check the Response or the
+// decoded responseCode. Neither Do nor the client methods retry failed
+// requests on their own; a retrying Middleware added with Use applies to both.
+// Requests made with NewRequest can be sent again with their GetBody
+func (vi VoiceIt2) Do(req *http.Request, out interface{}) (*Response, error) {
+	resp, err := vi.roundTrip(req)
+	if err != nil {
+		return nil, errors.New("Do error: " + err.Error())
+	}
+	if out != nil {
+		if err := json.Unmarshal(resp.Body, out); err != nil {
+			return resp, errors.New("Do error: cannot decode reply: " + err.Error())
+		}
+	}
+	return resp, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
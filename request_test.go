@@ -0,0 +1,137 @@
+package voiceit2
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/fakeapi"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+func TestNewRequest(t *testing.T) {
+	assert := assert.New(t)
+	api, myVoiceIt := newFakeClient(t)
+	dir, _ := ioutil.TempDir("", "request")
+	defer os.RemoveAll(dir)
+	userId := api.AddUser()
+	ctx := context.Background()
+
+	var urls []string
+	myVoiceIt.AddNotificationUrl("https://example.com/hook")
+	myVoiceIt.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			urls = append(urls, req.URL.RequestURI())
+			return next(req)
+		}
+	})
+
+	req, err := myVoiceIt.NewRequest(ctx, "GET", "users/"+userId+"/groups", nil, nil)
+	assert.Equal(nil, err)
+	assert.Nil(req.Body)
+	var groups structs.GetGroupsForUserReturn
+	resp, err := myVoiceIt.Do(req, &groups)
+	assert.Equal(nil, err)
+	assert.Equal("SUCC", groups.ResponseCode)
+	assert.Equal(200, resp.StatusCode)
+	assert.Equal("/users/"+userId+"/groups?notificationURL=https%3A%2F%2Fexample.com%2Fhook", urls[0])
+
+	recording := filepath.Join(dir, "sample.wav")
+	ioutil.WriteFile(recording, []byte("ann\nnever forget tomorrow is a new day"), 0600)
+	fields := map[string]string{"userId": userId, "contentLanguage": "en-US", "phrase": "never forget tomorrow is a new day"}
+	req, err = myVoiceIt.NewRequest(ctx, "POST", "/enrollments/voice?extra=1", fields, map[string]string{"recording": recording})
+	assert.Equal(nil, err)
+	resp, err = myVoiceIt.Do(req, nil)
+	assert.Equal(nil, err)
+	assert.Contains(string(resp.Body), `"responseCode":"SUCC"`)
+	assert.Equal(1, api.EnrollmentCount(userId, "voice"))
+	assert.Equal("/enrollments/voice?extra=1&notificationURL=https%3A%2F%2Fexample.com%2Fhook", urls[1])
+
+	_, err = myVoiceIt.NewRequest(ctx, "POST", "/enrollments/voice", nil, map[string]string{"recording": filepath.Join(dir, "missing.wav")})
+	assert.Contains(err.Error(), "NewRequest error")
+
+	var wrong []string
+	req, _ = myVoiceIt.NewRequest(ctx, "GET", "/users/"+userId, nil, nil)
+	resp, err = myVoiceIt.Do(req, &wrong)
+	assert.Contains(err.Error(), "Do error: cannot decode reply")
+	assert.NotNil(resp)
+}
+
+func TestNewEndpointRequest(t *testing.T) {
+	assert := assert.New(t)
+	api := fakeapi.New("key", "tok")
+	defer api.Close()
+	userId := api.AddUser()
+
+	var hosts []string
+	myVoiceIt := NewClient("key", "tok")
+	myVoiceIt.BaseUrl = "http://127.0.0.1:1"
+	myVoiceIt.LivenessUrl = api.URL
+	myVoiceIt.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			hosts = append(hosts, req.URL.Scheme+"://"+req.URL.Host+req.URL.Path)
+			return next(req)
+		}
+	})
+
+	e, _ := EndpointByName("GetLivenessChallenge")
+	params := Params{"userId": userId, "contentLanguage": "en_us"}
+	req, err := myVoiceIt.NewEndpointRequest(context.Background(), e, params)
+	assert.Equal(nil, err)
+	var challenge structs.LivenessChallengeReturn
+	_, err = myVoiceIt.Do(req, &challenge)
+	assert.Equal(nil, err)
+	assert.Equal("SUCC", challenge.ResponseCode)
+	assert.Equal([]string{api.URL + "/v1/verification/" + userId + "/en-US"}, hosts, "liveness endpoints are sent to LivenessUrl")
+	assert.Equal("en_us", params["contentLanguage"], "the caller's parameters are left alone")
+
+	e, _ = EndpointByName("GetAllUsers")
+	req, _ = myVoiceIt.NewEndpointRequest(context.Background(), e, nil)
+	assert.Equal("http://127.0.0.1:1/users", req.URL.String())
+
+	e, _ = EndpointByName("GetPhrases")
+	_, err = myVoiceIt.NewEndpointRequest(context.Background(), e, Params{"contentLanguage": "xx-YY"})
+	assert.Contains(err.Error(), "NewEndpointRequest error: unsupported content language")
+}
+
+func TestDoRetryMiddleware(t *testing.T) {
+	assert := assert.New(t)
+	api, myVoiceIt := newFakeClient(t)
+	dir, _ := ioutil.TempDir("", "request")
+	defer os.RemoveAll(dir)
+	userId := api.AddUser()
+
+	attempts := 0
+	myVoiceIt.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			for {
+				attempts++
+				resp, err := next(req)
+				if err != nil || resp.StatusCode < 500 || attempts == 3 {
+					return resp, err
+				}
+				resp.Body.Close()
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req.Body = body
+			}
+		}
+	})
+
+	api.Fail("POST /enrollments/voice", 1, 503)
+	recording := filepath.Join(dir, "sample.wav")
+	ioutil.WriteFile(recording, []byte("ann\nnever forget tomorrow is a new day"), 0600)
+	fields := map[string]string{"userId": userId, "contentLanguage": "en-US", "phrase": "never forget tomorrow is a new day"}
+	req, _ := myVoiceIt.NewRequest(context.Background(), "POST", "/enrollments/voice", fields, map[string]string{"recording": recording})
+	resp, err := myVoiceIt.Do(req, nil)
+	assert.Equal(nil, err)
+	assert.Equal(2, attempts)
+	assert.Equal(201, resp.StatusCode)
+	assert.Equal(1, api.EnrollmentCount(userId, "voice"), "the body is sent again in full")
+}
@@ -0,0 +1,218 @@
+// Package shard spreads users over several identification groups and identifies
+// a person by querying all of them at once.
+//
+// An Identifier covers a flat set of shards. A Tree arranges shards in a
+// hierarchy of branches, such as regions and countries, and identifies within
+// any branch and everything below it. Identification groups cannot contain
+// other groups, and descending only into the branches with the best
+// intermediate confidence could prune the branch holding the true match, so a
+// Tree queries every shard of the branch and picks the winner among all of them
+package shard
+
+import (
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apiutil"
+)
+
+// Reasons a Result has no winner
+const (
+	ReasonNoMatch = "no shard identified the person"
+	// ReasonLowConfidence is given when the best candidate is below MinConfidence
+	ReasonLowConfidence = "best candidate below minimum confidence"
+	// ReasonAmbiguous is given when the top two candidates are closer than Margin
+	ReasonAmbiguous = "top candidates are within the margin"
+	// ReasonIncomplete is given when a shard failed, since it may hold the true
+	// match, unless AllowPartial is set
+	ReasonIncomplete = "not every shard could be queried"
+)
+
+// Candidate is the best match of one shard
+type Candidate struct {
+	GroupId string
+	// Path is the Tree branch of the shard, empty for an Identifier
+	Path string
+	// UserId is empty if the shard did not identify anyone
+	UserId string
+	// Confidence is the match confidence. For video it is the lower of the voice
+	// and face confidences
+	Confidence   float64
+	ResponseCode string
+	Message      string
+	// Err is set when the shard could not be queried
+	Err error
+}
+
+// Result is the outcome of identifying a sample across all shards
+type Result struct {
+	// Winner is the globally identified user, nil if there is none
+	Winner *Candidate
+	// Reason explains a missing winner
+	Reason string
+	// Candidates holds every shard's answer, best match first
+	Candidates []Candidate
+}
+
+// Identifier runs identifications across a set of shard groups
+type Identifier struct {
+	Client   voiceit2.VoiceIt2
+	Modality voiceit2.Modality
+	// Shards are the groupIds of the shard groups. Their order defines the shard
+	// each user is assigned to, so it must not change once users are assigned
+	Shards []string
+	// ContentLanguage and Phrase are sent with voice and video samples
+	ContentLanguage string
+	Phrase          string
+	// MinConfidence is the lowest confidence a winner may have
+	MinConfidence float64
+	// Margin is how far the winner must be ahead of the next best other user
+	Margin float64
+	// Workers bounds the number of concurrent identifications. Defaults to one per shard
+	Workers int
+	// AllowPartial picks a winner among the shards that answered when others
+	// failed. The person may then be identified as someone else who resembles
+	// them, if their own shard failed
+	AllowPartial bool
+}
+
+// New returns an Identifier over the given shard groups
+func New(client voiceit2.VoiceIt2, modality voiceit2.Modality, shards ...string) *Identifier {
+	return &Identifier{Client: client, Modality: modality, Shards: shards}
+}
+
+// ShardFor returns the shard group a user belongs to
+func (id *Identifier) ShardFor(userId string) string {
+	if len(id.Shards) == 0 {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(userId))
+	return id.Shards[h.Sum32()%uint32(len(id.Shards))]
+}
+
+// Assign adds the user to its shard group with AddUserToGroup and returns the group
+func (id *Identifier) Assign(userId string) (string, error) {
+	groupId := id.ShardFor(userId)
+	if groupId == "" {
+		return "", errors.New("Assign error: no shards")
+	}
+	ret, err := id.Client.AddUserToGroup(groupId, userId)
+	if err := apiutil.Decode("AddUserToGroup", ret, err, nil); err != nil {
+		return "", errors.New("Assign error: " + err.Error())
+	}
+	return groupId, nil
+}
+
+// Identify runs the identification of the sample at filePath against every shard
+// concurrently and picks the global winner. Shards that fail are reported in the
+// candidates and leave the result without a winner, see AllowPartial. An error
+// is only returned if every shard failed
+func (id *Identifier) Identify(filePath string) (*Result, error) {
+	if !id.Modality.Valid() {
+		return nil, errors.New("Identify error: unknown modality \"" + string(id.Modality) + "\"")
+	}
+	if len(id.Shards) == 0 {
+		return nil, errors.New("Identify error: no shards")
+	}
+	workers := id.Workers
+	if workers <= 0 || workers > len(id.Shards) {
+		workers = len(id.Shards)
+	}
+
+	candidates := make([]Candidate, len(id.Shards))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				candidates[i] = id.identify(id.Shards[i], filePath)
+			}
+		}()
+	}
+	for i := range id.Shards {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	failed := 0
+	for _, c := range candidates {
+		if c.Err != nil {
+			failed++
+		}
+	}
+	if failed == len(candidates) {
+		return nil, errors.New("Identify error: all " + strconv.Itoa(failed) + " shards failed, first error: " + candidates[0].Err.Error())
+	}
+	res := id.decide(candidates)
+	if failed > 0 && !id.AllowPartial {
+		res.Winner = nil
+		res.Reason = ReasonIncomplete
+	}
+	return res, nil
+}
+
+// decide sorts the candidates and picks the winner
+func (id *Identifier) decide(candidates []Candidate) *Result {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if (candidates[i].UserId != "") != (candidates[j].UserId != "") {
+			return candidates[i].UserId != ""
+		}
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	res := &Result{Candidates: candidates}
+	best := candidates[0]
+	switch {
+	case best.UserId == "":
+		res.Reason = ReasonNoMatch
+		return res
+	case best.Confidence < id.MinConfidence:
+		res.Reason = ReasonLowConfidence
+		return res
+	}
+	// A user found in two shards is not a competitor of itself
+	for _, c := range candidates[1:] {
+		if c.UserId != "" && c.UserId != best.UserId {
+			if best.Confidence-c.Confidence < id.Margin {
+				res.Reason = ReasonAmbiguous
+				return res
+			}
+			break
+		}
+	}
+	res.Winner = &candidates[0]
+	return res
+}
+
+func (id *Identifier) identify(groupId string, filePath string) Candidate {
+	c := Candidate{GroupId: groupId}
+	call, ret, err := apiutil.Identify(id.Client, id.Modality, groupId, id.ContentLanguage, id.Phrase, filePath)
+	if err != nil {
+		c.Err = err
+		return c
+	}
+	m, err := apiutil.DecodeMatch(call, ret)
+	if err != nil {
+		c.Err = err
+		return c
+	}
+	c.ResponseCode, c.Message = m.ResponseCode, m.Message
+	switch apiErr := m.APIError(call); {
+	case m.ResponseCode == "SUCC":
+		c.UserId = m.UserId
+	case m.ResponseCode == "FAIL" && !apiErr.Temporary():
+		// Nobody in this shard matched
+	default:
+		c.Err = apiErr
+		return c
+	}
+	c.Confidence = m.Confidence
+	return c
+}
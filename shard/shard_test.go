@@ -0,0 +1,151 @@
+package shard
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apitest"
+)
+
+func TestIdentify(t *testing.T) {
+	assert := assert.New(t)
+	api, myVoiceIt := apitest.New(t)
+	dir, _ := ioutil.TempDir("", "shard")
+	defer os.RemoveAll(dir)
+	sample := func(content string) string {
+		f, _ := ioutil.TempFile(dir, "sample")
+		f.WriteString(content)
+		f.Close()
+		return f.Name()
+	}
+
+	id := New(myVoiceIt, voiceit2.Voice, api.AddGroup("shard-0"), api.AddGroup("shard-1"), api.AddGroup("shard-2"))
+	id.ContentLanguage = "en-US"
+	id.Phrase = "never forget tomorrow is a new day"
+	id.Margin = 10
+
+	speakers := map[string]string{}
+	for _, name := range []string{"ann", "ben", "cat", "dan", "eve", "fay"} {
+		userId := api.AddUser()
+		speakers[name] = userId
+		groupId, err := id.Assign(userId)
+		assert.Equal(nil, err)
+		assert.Equal(id.ShardFor(userId), groupId)
+		_, err = myVoiceIt.CreateVoiceEnrollment(userId, id.ContentLanguage, id.Phrase, sample(name+"\nenrollment"))
+		assert.Equal(nil, err)
+	}
+
+	res, err := id.Identify(sample("dan\nattempt"))
+	assert.Equal(nil, err)
+	assert.NotNil(res.Winner)
+	assert.Equal(speakers["dan"], res.Winner.UserId)
+	assert.Equal(id.ShardFor(speakers["dan"]), res.Winner.GroupId)
+	assert.Equal(3, len(res.Candidates))
+
+	res, _ = id.Identify(sample("zed\nattempt"))
+	assert.Nil(res.Winner)
+	assert.Equal(ReasonNoMatch, res.Reason)
+
+	// Two people sharing a print in different shards cannot be told apart
+	twin := api.AddUser()
+	for id.ShardFor(twin) == id.ShardFor(speakers["dan"]) {
+		twin = api.AddUser()
+	}
+	id.Assign(twin)
+	myVoiceIt.CreateVoiceEnrollment(twin, id.ContentLanguage, id.Phrase, sample("dan\ntwin"))
+	res, _ = id.Identify(sample("dan\nattempt"))
+	assert.Nil(res.Winner)
+	assert.Equal(ReasonAmbiguous, res.Reason)
+
+	// A failed shard does not fail the identification, but may hold the true
+	// match, so there is no winner unless partial results are allowed
+	id.Workers = 1
+	gus := api.AddUser()
+	for id.ShardFor(gus) != id.Shards[0] {
+		gus = api.AddUser()
+	}
+	id.Assign(gus)
+	myVoiceIt.CreateVoiceEnrollment(gus, id.ContentLanguage, id.Phrase, sample("gus\nenrollment"))
+	lookalike := api.AddUser()
+	for id.ShardFor(lookalike) == id.Shards[0] {
+		lookalike = api.AddUser()
+	}
+	id.Assign(lookalike)
+	myVoiceIt.CreateVoiceEnrollment(lookalike, id.ContentLanguage, id.Phrase, sample("gus\nlookalike"))
+	api.Fail("POST /identification/voice", 1, 500)
+	res, err = id.Identify(sample("gus\nattempt"))
+	assert.Equal(nil, err)
+	assert.Nil(res.Winner, "the lookalike must not win while the shard of gus is missing")
+	assert.Equal(ReasonIncomplete, res.Reason)
+	failed := 0
+	for _, c := range res.Candidates {
+		if c.Err != nil {
+			failed++
+			assert.Equal(id.Shards[0], c.GroupId)
+		}
+	}
+	assert.Equal(1, failed)
+
+	id.AllowPartial = true
+	api.Fail("POST /identification/voice", 1, 500)
+	res, err = id.Identify(sample("gus\nattempt"))
+	assert.Equal(nil, err)
+	assert.NotNil(res.Winner)
+	assert.Equal(lookalike, res.Winner.UserId, "partial results can pick the wrong person")
+
+	api.Fail("POST /identification/voice", 3, 500)
+	_, err = id.Identify(sample("ann\nattempt"))
+	assert.NotEqual(nil, err)
+}
+
+func TestTree(t *testing.T) {
+	assert := assert.New(t)
+	api, myVoiceIt := apitest.New(t)
+	dir, _ := ioutil.TempDir("", "shard")
+	defer os.RemoveAll(dir)
+	sample := func(content string) string {
+		f, _ := ioutil.TempFile(dir, "sample")
+		f.WriteString(content)
+		f.Close()
+		return f.Name()
+	}
+
+	tree := NewTree(Identifier{Client: myVoiceIt, Modality: voiceit2.Face, Margin: 10})
+	tree.Add("eu/de", api.AddGroup("de-0"), api.AddGroup("de-1"))
+	tree.Add("/eu/fr/", api.AddGroup("fr-0"))
+	tree.Add("us", api.AddGroup("us-0"))
+	assert.Equal([]string{"eu/de", "eu/fr", "us"}, tree.Paths())
+
+	people := map[string]string{}
+	for name, path := range map[string]string{"ann": "eu/de", "ben": "eu/fr", "cat": "us"} {
+		userId := api.AddUser()
+		people[name] = userId
+		_, err := tree.Assign(path, userId)
+		assert.Equal(nil, err)
+		_, err = myVoiceIt.CreateFaceEnrollment(userId, sample(name+"\nenrollment"))
+		assert.Equal(nil, err)
+	}
+
+	res, err := tree.Identify("", sample("ann\nattempt"))
+	assert.Equal(nil, err)
+	assert.Equal(people["ann"], res.Winner.UserId)
+	assert.Equal("eu/de", res.Winner.Path)
+	assert.Equal(4, len(res.Candidates))
+
+	// A branch covers the branches below it and nothing else
+	res, _ = tree.Identify("eu", sample("ben\nattempt"))
+	assert.Equal(people["ben"], res.Winner.UserId)
+	assert.Equal("eu/fr", res.Winner.Path)
+	assert.Equal(3, len(res.Candidates))
+	res, _ = tree.Identify("eu", sample("cat\nattempt"))
+	assert.Nil(res.Winner)
+	assert.Equal(ReasonNoMatch, res.Reason)
+
+	_, err = tree.Identify("e", sample("ann\nattempt"))
+	assert.Contains(err.Error(), "no shards under")
+	_, err = tree.Assign("eu", people["ann"])
+	assert.Contains(err.Error(), "unknown branch")
+}
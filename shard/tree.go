@@ -0,0 +1,101 @@
+package shard
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Tree arranges shard groups in a hierarchy of branches named by
+// slash-separated paths, such as "eu" and "eu/de". A branch covers its own
+// shards and those of every branch below it
+type Tree struct {
+	// Settings holds the client, modality and decision settings of every
+	// identification. Its Shards are not used; each branch has its own
+	Settings Identifier
+	branches map[string][]string
+}
+
+// NewTree returns an empty Tree that identifies with the settings of id
+func NewTree(id Identifier) *Tree {
+	id.Shards = nil
+	return &Tree{Settings: id, branches: map[string][]string{}}
+}
+
+// Add sets the shard groups of the branch at path. As with Identifier.Shards,
+// their order defines the shard each user of the branch is assigned to
+func (t *Tree) Add(path string, shards ...string) {
+	t.branches[cleanPath(path)] = shards
+}
+
+// Paths returns the paths of the branches in sorted order
+func (t *Tree) Paths() []string {
+	paths := make([]string, 0, len(t.branches))
+	for path := range t.branches {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Identifier returns an Identifier over the shards of the branch at path and
+// the branches below it. An empty path covers the whole tree
+func (t *Tree) Identifier(path string) (*Identifier, error) {
+	path = cleanPath(path)
+	id := t.Settings
+	id.Shards = nil
+	for _, p := range t.Paths() {
+		if within(p, path) {
+			id.Shards = append(id.Shards, t.branches[p]...)
+		}
+	}
+	if len(id.Shards) == 0 {
+		return nil, errors.New("no shards under \"" + path + "\"")
+	}
+	return &id, nil
+}
+
+// Assign adds the user to its shard group in the branch at path with
+// AddUserToGroup and returns the group. Only the branch's own shards are used
+func (t *Tree) Assign(path string, userId string) (string, error) {
+	shards, ok := t.branches[cleanPath(path)]
+	if !ok {
+		return "", errors.New("Assign error: unknown branch \"" + path + "\"")
+	}
+	id := t.Settings
+	id.Shards = shards
+	return id.Assign(userId)
+}
+
+// Identify identifies the sample at filePath across every shard of the branch
+// at path and below, as Identifier.Identify does, and sets the Path of each
+// candidate. An empty path searches the whole tree
+func (t *Tree) Identify(path string, filePath string) (*Result, error) {
+	id, err := t.Identifier(path)
+	if err != nil {
+		return nil, errors.New("Identify error: " + err.Error())
+	}
+	res, err := id.Identify(filePath)
+	if err != nil {
+		return nil, err
+	}
+	paths := map[string]string{}
+	for p, shards := range t.branches {
+		for _, groupId := range shards {
+			paths[groupId] = p
+		}
+	}
+	for i := range res.Candidates {
+		res.Candidates[i].Path = paths[res.Candidates[i].GroupId]
+	}
+	return res, nil
+}
+
+func cleanPath(path string) string {
+	return strings.Trim(path, "/")
+}
+
+// within reports whether the branch at p is at or below the branch at parent
+func within(p, parent string) bool {
+	return parent == "" || p == parent || strings.HasPrefix(p, parent+"/")
+}
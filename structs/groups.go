@@ -1,10 +1,11 @@
 package structs
 
 type Group struct {
-	CreatedAt int      `json:"createdAt"`
-	GroupId   string   `json:"groupId"`
-	Users     []string `json:"users"`
-	UserCount int      `json:"userCount"`
+	CreatedAt   int      `json:"createdAt"`
+	GroupId     string   `json:"groupId"`
+	Description string   `json:"description"`
+	Users       []string `json:"users"`
+	UserCount   int      `json:"userCount"`
 }
 
 type GetAllGroupsReturn struct {
@@ -18,6 +19,8 @@ type GetAllGroupsReturn struct {
 
 type GetGroupReturn struct {
 	Message      string   `json:"message"`
+	GroupId      string   `json:"groupId"`
+	Description  string   `json:"description"`
 	CreatedAt    int      `json:"createdAt"`
 	Users        []string `json:"users"`
 	UserCount    int      `json:"userCount"`
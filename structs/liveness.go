@@ -0,0 +1,24 @@
+package structs
+
+type LivenessChallengeReturn struct {
+	Message               string   `json:"message"`
+	Status                int      `json:"status"`
+	Success               bool     `json:"success"`
+	LcoId                 string   `json:"lcoId"`
+	Lco                   []string `json:"lco"`
+	UILivenessInstruction string   `json:"uiLivenessInstruction"`
+	TimeTaken             string   `json:"timeTaken"`
+	ResponseCode          string   `json:"responseCode"`
+}
+
+type LivenessVerificationReturn struct {
+	Message         string  `json:"message"`
+	Status          int     `json:"status"`
+	Success         bool    `json:"success"`
+	FaceConfidence  float64 `json:"faceConfidence"`
+	VoiceConfidence float64 `json:"voiceConfidence"`
+	Text            string  `json:"text"`
+	TextConfidence  float64 `json:"textConfidence"`
+	TimeTaken       string  `json:"timeTaken"`
+	ResponseCode    string  `json:"responseCode"`
+}
@@ -0,0 +1,217 @@
+package tenants
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apiutil"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+// liveCredential is the credential shared by all clients of a tenant. Rotations
+// replace it in place, so clients handed out earlier pick up the new token
+type liveCredential struct {
+	mu       sync.RWMutex
+	cred     Credential
+	rotating chan struct{} // closed when the rotation in progress finishes; nil if none
+}
+
+func (l *liveCredential) current() Credential {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.cred
+}
+
+func (l *liveCredential) set(cred Credential) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cred = cred
+}
+
+// beginRotation marks a rotation in progress. It fails if one already is
+func (l *liveCredential) beginRotation() (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rotating != nil {
+		return nil, errors.New("rotation already in progress")
+	}
+	done := make(chan struct{})
+	l.rotating = done
+	return func() {
+		l.mu.Lock()
+		l.rotating = nil
+		l.mu.Unlock()
+		close(done)
+	}, nil
+}
+
+// awaitRotation waits up to timeout for a rotation in progress to finish
+func (l *liveCredential) awaitRotation(timeout time.Duration) {
+	l.mu.RLock()
+	done := l.rotating
+	l.mu.RUnlock()
+	if done == nil {
+		return
+	}
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// live returns the shared credential of a tenant, loading it from the vault on first use
+func (m *Manager) live(tenant string) (*liveCredential, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if l, ok := m.lives[tenant]; ok {
+		return l, nil
+	}
+	cred, err := m.credential(tenant)
+	if err != nil {
+		return nil, err
+	}
+	if m.lives == nil {
+		m.lives = map[string]*liveCredential{}
+	}
+	l := &liveCredential{cred: cred}
+	m.lives[tenant] = l
+	return l, nil
+}
+
+// forget drops the shared credential of a deleted tenant
+func (m *Manager) forget(tenant string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.lives, tenant)
+}
+
+// Rotate regenerates the API token of the tenant's sub-account with
+// RegenerateSubAccountAPIToken, stores it and swaps it into the tenant's clients.
+// Requests rejected with the old token while the rotation is in progress are
+// retried with the new one
+func (m *Manager) Rotate(tenant string) error {
+	l, err := m.live(tenant)
+	if err != nil {
+		return errors.New("Rotate error: " + err.Error())
+	}
+	finish, err := l.beginRotation()
+	if err != nil {
+		return errors.New("Rotate error: " + tenant + ": " + err.Error())
+	}
+	defer finish()
+
+	cred := l.current()
+	ret, err := m.Master.RegenerateSubAccountAPIToken(cred.APIKey)
+	var rsat structs.RegenerateSubAccountAPITokenReturn
+	if err := apiutil.Decode("RegenerateSubAccountAPIToken", ret, err, &rsat); err != nil {
+		return errors.New("Rotate error: " + err.Error())
+	}
+
+	// The old token stopped working with the call above, so the new one is
+	// swapped in even if storing it fails
+	cred.APIToken = rsat.APIToken
+	l.set(cred)
+	if err := m.Vault.Put(tenant, cred); err != nil {
+		return errors.New("Rotate error: the new token is in use but could not be stored: " + err.Error())
+	}
+	return nil
+}
+
+// ScheduleRotation rotates the tokens of the given tenants, or of all tenants in
+// the vault if none are given, every period until the returned stop function is
+// called. Failures are passed to onError, which may be nil. The period must be
+// positive
+func (m *Manager) ScheduleRotation(period time.Duration, onError func(tenant string, err error), tenants ...string) (stop func(), err error) {
+	if period <= 0 {
+		return nil, errors.New("ScheduleRotation error: period must be positive")
+	}
+	quit := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+			}
+			targets := tenants
+			if len(targets) == 0 {
+				var err error
+				if targets, err = m.Vault.Tenants(); err != nil {
+					if onError != nil {
+						onError("", err)
+					}
+					continue
+				}
+			}
+			for _, tenant := range targets {
+				if err := m.Rotate(tenant); err != nil && onError != nil {
+					onError(tenant, err)
+				}
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(quit) }) }, nil
+}
+
+// authTransport sets the tenant's current credential on every request and
+// retries requests rejected during a token swap. It runs after the client's
+// middleware, so it replaces any Authorization header middleware set
+type authTransport struct {
+	base    http.RoundTripper
+	manager *Manager
+	tenant  string
+	live    *liveCredential
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cred := t.live.current()
+	for attempt := 0; ; attempt++ {
+		resp, err := t.send(req, cred)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized || attempt >= t.manager.authRetries() {
+			return resp, err
+		}
+
+		// The token may have been regenerated after the request was sent. Wait for a
+		// rotation in progress, then check whether the vault holds a newer token,
+		// such as one stored by another Manager sharing it
+		t.live.awaitRotation(t.manager.swapWait())
+		next := t.live.current()
+		if next.APIToken == cred.APIToken {
+			if stored, ok, verr := t.manager.Vault.Get(t.tenant); verr == nil && ok && stored.APIToken != cred.APIToken {
+				t.live.set(stored)
+				next = stored
+			}
+		}
+		if next.APIToken == cred.APIToken || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		cred = next
+	}
+}
+
+// send performs one attempt on a copy of req, since a RoundTripper must not modify its request
+func (t *authTransport) send(req *http.Request, cred Credential) (*http.Response, error) {
+	attempt := req.WithContext(req.Context())
+	attempt.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		attempt.Header[k] = v
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		attempt.Body = body
+	}
+	attempt.SetBasicAuth(cred.APIKey, cred.APIToken)
+	return t.base.RoundTrip(attempt)
+}
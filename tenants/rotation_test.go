@@ -0,0 +1,81 @@
+package tenants
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/fakeapi"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+func newRotationManager(t *testing.T) (*fakeapi.Server, *Manager, Credential) {
+	api := fakeapi.New("key", "tok")
+	master := voiceit2.NewClient("key", "tok")
+	master.BaseUrl = api.URL
+	m := NewManager(master, NewMemoryVault())
+	cred, err := m.Create("acme", Managed, structs.CreateSubAccountRequest{FirstName: "Acme"})
+	if err != nil {
+		api.Close()
+		t.Fatal(err)
+	}
+	return api, m, cred
+}
+
+func TestRotate(t *testing.T) {
+	assert := assert.New(t)
+	api, m, cred := newRotationManager(t)
+	defer api.Close()
+
+	client, err := m.ClientFor("acme")
+	assert.Equal(nil, err)
+	assert.Equal(nil, m.Rotate("acme"))
+
+	stored, _, _ := m.Vault.Get("acme")
+	assert.NotEqual(cred.APIToken, stored.APIToken)
+	assert.Equal(api.SubAccountToken(cred.APIKey), stored.APIToken)
+
+	// A client handed out before the rotation uses the new token
+	ret, err := client.CreateUser()
+	assert.Equal(nil, err)
+	assert.Contains(string(ret), `"responseCode":"SUCC"`)
+}
+
+func TestRotatedElsewhere(t *testing.T) {
+	assert := assert.New(t)
+	api, m, cred := newRotationManager(t)
+	defer api.Close()
+	client, _ := m.ClientFor("acme")
+
+	// Another process regenerates the token and stores it in the shared vault
+	other := NewManager(m.Master, m.Vault)
+	assert.Equal(nil, other.Rotate("acme"))
+	assert.NotEqual(cred.APIToken, api.SubAccountToken(cred.APIKey))
+
+	ret, err := client.CreateUser()
+	assert.Equal(nil, err)
+	assert.Contains(string(ret), `"responseCode":"SUCC"`, "rejected request is retried with the stored token")
+}
+
+func TestScheduleRotation(t *testing.T) {
+	assert := assert.New(t)
+	api, m, cred := newRotationManager(t)
+	defer api.Close()
+
+	var failures int32
+	_, err := m.ScheduleRotation(0, nil)
+	assert.NotEqual(nil, err, "a zero period is refused instead of panicking")
+
+	stop, err := m.ScheduleRotation(10*time.Millisecond, func(string, error) { atomic.AddInt32(&failures, 1) })
+	assert.Equal(nil, err)
+	deadline := time.Now().Add(2 * time.Second)
+	for api.SubAccountToken(cred.APIKey) == cred.APIToken && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop()
+	stop()
+	assert.NotEqual(cred.APIToken, api.SubAccountToken(cred.APIKey))
+	assert.Equal(int32(0), atomic.LoadInt32(&failures))
+}
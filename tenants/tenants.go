@@ -0,0 +1,194 @@
+// Package tenants manages sub-accounts on behalf of tenants of a multi-tenant
+// application: it creates them with the master account, keeps their credentials
+// in a vault and hands out clients scoped to a tenant
+package tenants
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apiutil"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+// Sub-account types
+const (
+	Managed   = "managed"
+	Unmanaged = "unmanaged"
+)
+
+// ErrUnknownTenant is returned for tenants that are not in the vault
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// Manager creates and deletes tenant sub-accounts with the master account's client
+type Manager struct {
+	Master voiceit2.VoiceIt2
+	Vault  Vault
+	// AuthRetries is how many times a tenant client retries a request rejected
+	// as unauthorized once a newer token is available. Defaults to 2
+	AuthRetries int
+	// SwapWait bounds how long a rejected request waits for a token rotation in
+	// progress to finish. Defaults to ten seconds
+	SwapWait time.Duration
+
+	mu    sync.Mutex
+	lives map[string]*liveCredential
+}
+
+// NewManager returns a Manager. master must hold the master account's API key and token
+func NewManager(master voiceit2.VoiceIt2, vault Vault) *Manager {
+	return &Manager{Master: master, Vault: vault, AuthRetries: 2, SwapWait: 10 * time.Second}
+}
+
+func (m *Manager) authRetries() int {
+	if m.AuthRetries <= 0 {
+		return 2
+	}
+	return m.AuthRetries
+}
+
+func (m *Manager) swapWait() time.Duration {
+	if m.SwapWait <= 0 {
+		return 10 * time.Second
+	}
+	return m.SwapWait
+}
+
+// Create creates a managed or unmanaged sub-account for a new tenant and stores its credentials
+func (m *Manager) Create(tenant string, subAccountType string, params structs.CreateSubAccountRequest) (Credential, error) {
+	if tenant == "" {
+		return Credential{}, errors.New("Create error: empty tenant")
+	}
+	if _, ok, err := m.Vault.Get(tenant); err != nil || ok {
+		if err == nil {
+			err = errors.New("tenant " + tenant + " already exists")
+		}
+		return Credential{}, errors.New("Create error: " + err.Error())
+	}
+
+	var ret []byte
+	var err error
+	var call string
+	switch subAccountType {
+	case Managed:
+		call = "CreateManagedSubAccount"
+		ret, err = m.Master.CreateManagedSubAccount(params)
+	case Unmanaged:
+		call = "CreateUnmanagedSubAccount"
+		ret, err = m.Master.CreateUnmanagedSubAccount(params)
+	default:
+		return Credential{}, errors.New("Create error: unknown sub-account type \"" + subAccountType + "\"")
+	}
+	var csa structs.CreateSubAccountReturn
+	if err := apiutil.Decode(call, ret, err, &csa); err != nil {
+		return Credential{}, errors.New("Create error: " + err.Error())
+	}
+
+	cred := Credential{APIKey: csa.APIKey, APIToken: csa.APIToken, Type: subAccountType}
+	if csa.Type != "" {
+		cred.Type = csa.Type
+	}
+	if err := m.Vault.Put(tenant, cred); err != nil {
+		// Without stored credentials the sub-account could never be used again
+		m.Master.DeleteSubAccount(csa.APIKey)
+		return Credential{}, errors.New("Create error: " + err.Error())
+	}
+	return cred, nil
+}
+
+// Register adds the credentials of an existing sub-account for a tenant
+func (m *Manager) Register(tenant string, cred Credential) error {
+	if tenant == "" || cred.APIKey == "" {
+		return errors.New("Register error: tenant and API key are required")
+	}
+	if err := m.Vault.Put(tenant, cred); err != nil {
+		return errors.New("Register error: " + err.Error())
+	}
+	m.forget(tenant)
+	return nil
+}
+
+// credential returns the stored credential of a tenant or ErrUnknownTenant
+func (m *Manager) credential(tenant string) (Credential, error) {
+	cred, ok, err := m.Vault.Get(tenant)
+	if err != nil {
+		return Credential{}, err
+	}
+	if !ok {
+		return Credential{}, ErrUnknownTenant
+	}
+	return cred, nil
+}
+
+// ClientFor returns a client that acts as the tenant's sub-account. It keeps the
+// master client's other settings, such as its base URL. The client always
+// authenticates with the tenant's current token, including after Rotate; an
+// Authorization header set by the master's middleware is replaced
+func (m *Manager) ClientFor(tenant string) (voiceit2.VoiceIt2, error) {
+	l, err := m.live(tenant)
+	if err != nil {
+		return voiceit2.VoiceIt2{}, err
+	}
+	cred := l.current()
+	client := m.Master
+	client.CredentialsProvider = nil
+	client.APIKey = cred.APIKey
+	client.APIToken = cred.APIToken
+	if cred.BaseUrl != "" {
+		client.BaseUrl = cred.BaseUrl
+	}
+
+	// Keep the master's Jar, CheckRedirect and Timeout, wrapping only its transport
+	hc := &http.Client{}
+	if m.Master.HTTPClient != nil {
+		*hc = *m.Master.HTTPClient
+	}
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	hc.Transport = &authTransport{base: base, manager: m, tenant: tenant, live: l}
+	client.HTTPClient = hc
+	return client, nil
+}
+
+// SwitchType switches the tenant's sub-account between managed and unmanaged
+// with SwitchSubAccountType and returns the new type
+func (m *Manager) SwitchType(tenant string) (string, error) {
+	cred, err := m.credential(tenant)
+	if err != nil {
+		return "", err
+	}
+	ret, err := m.Master.SwitchSubAccountType(cred.APIKey)
+	var ssat structs.SwitchSubAccountTypeReturn
+	if err := apiutil.Decode("SwitchSubAccountType", ret, err, &ssat); err != nil {
+		return "", errors.New("SwitchType error: " + err.Error())
+	}
+	cred.Type = ssat.Type
+	if err := m.Vault.Put(tenant, cred); err != nil {
+		return "", errors.New("SwitchType error: " + err.Error())
+	}
+	m.forget(tenant)
+	return cred.Type, nil
+}
+
+// Delete deletes the tenant's sub-account with DeleteSubAccount and removes its
+// credentials
+func (m *Manager) Delete(tenant string) error {
+	cred, err := m.credential(tenant)
+	if err != nil {
+		return err
+	}
+	ret, err := m.Master.DeleteSubAccount(cred.APIKey)
+	if err := apiutil.Decode("DeleteSubAccount", ret, err, nil); err != nil {
+		return errors.New("Delete error: " + err.Error())
+	}
+	if err := m.Vault.Delete(tenant); err != nil {
+		return errors.New("Delete error: " + err.Error())
+	}
+	m.forget(tenant)
+	return nil
+}
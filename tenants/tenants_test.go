@@ -0,0 +1,109 @@
+package tenants
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apitest"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/fakeapi"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+func TestEncryptedFileVault(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "tenants")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "vault")
+	key := bytes.Repeat([]byte{7}, 32)
+
+	v, err := OpenEncryptedFileVault(path, key)
+	assert.Equal(nil, err)
+	assert.Equal(nil, v.Put("acme", Credential{APIKey: "key_1", APIToken: "tok_secret", Type: Managed}))
+
+	raw, _ := ioutil.ReadFile(path)
+	assert.False(bytes.Contains(raw, []byte("tok_secret")), "vault file must not contain plaintext tokens")
+
+	reopened, err := OpenEncryptedFileVault(path, key)
+	assert.Equal(nil, err)
+	cred, ok, _ := reopened.Get("acme")
+	assert.True(ok)
+	assert.Equal("tok_secret", cred.APIToken)
+
+	_, err = OpenEncryptedFileVault(path, bytes.Repeat([]byte{8}, 32))
+	assert.NotEqual(nil, err, "wrong key must be rejected")
+}
+
+func TestManager(t *testing.T) {
+	assert := assert.New(t)
+	api := fakeapi.New("key", "tok")
+	defer api.Close()
+	master := voiceit2.NewClientWithProvider(voiceit2.StaticCredentials{APIKey: "key", APIToken: "tok"})
+	master.BaseUrl = api.URL
+	m := NewManager(master, NewMemoryVault())
+
+	cred, err := m.Create("acme", Managed, structs.CreateSubAccountRequest{FirstName: "Acme", Email: "ops@acme.test"})
+	assert.Equal(nil, err)
+	assert.Equal(Managed, cred.Type)
+	_, err = m.Create("acme", Managed, structs.CreateSubAccountRequest{})
+	assert.NotEqual(nil, err, "tenants cannot be created twice")
+
+	client, err := m.ClientFor("acme")
+	assert.Equal(nil, err)
+	assert.Equal(cred.APIKey, client.APIKey)
+	assert.Equal(nil, client.CredentialsProvider, "the master's provider is not used for the tenant")
+	assert.Equal(api.URL, client.BaseUrl)
+	ret, err := client.CreateUser()
+	assert.Equal(nil, err)
+	assert.Contains(string(ret), `"responseCode":"SUCC"`)
+
+	newType, err := m.SwitchType("acme")
+	assert.Equal(nil, err)
+	assert.Equal(Unmanaged, newType)
+	stored, _, _ := m.Vault.Get("acme")
+	assert.Equal(Unmanaged, stored.Type)
+
+	assert.Equal(nil, m.Delete("acme"))
+	_, err = m.ClientFor("acme")
+	assert.Equal(ErrUnknownTenant, err)
+	ret, _ = client.CreateUser()
+	assert.Contains(string(ret), `"responseCode":"UNAC"`, "deleted sub-account credentials must stop working")
+}
+
+func TestClientForKeepsHTTPClient(t *testing.T) {
+	assert := assert.New(t)
+	_, master := apitest.New(t)
+	jar, _ := cookiejar.New(nil)
+	redirects := 0
+	master.HTTPClient = &http.Client{
+		Jar:           jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error { redirects++; return nil },
+		Timeout:       time.Minute,
+	}
+	m := NewManager(master, NewMemoryVault())
+	m.Create("acme", Managed, structs.CreateSubAccountRequest{})
+
+	client, err := m.ClientFor("acme")
+	assert.Equal(nil, err)
+	assert.True(client.HTTPClient != master.HTTPClient, "the master's client is not modified")
+	assert.Equal(jar, client.HTTPClient.Jar)
+	assert.Equal(time.Minute, client.HTTPClient.Timeout)
+	assert.NotNil(client.HTTPClient.CheckRedirect)
+	client.HTTPClient.CheckRedirect(nil, nil)
+	assert.Equal(1, redirects)
+	transport, ok := client.HTTPClient.Transport.(*authTransport)
+	assert.True(ok)
+	assert.Equal(http.DefaultTransport, transport.base)
+	assert.Equal(nil, master.HTTPClient.Transport)
+
+	ret, err := client.CreateUser()
+	assert.Equal(nil, err)
+	assert.Contains(string(ret), `"responseCode":"SUCC"`)
+}
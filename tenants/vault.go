@@ -0,0 +1,163 @@
+package tenants
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/store"
+)
+
+// Credential holds what is needed to act as a sub-account
+type Credential struct {
+	APIKey   string `json:"apiKey"`
+	APIToken string `json:"apiToken"`
+	// BaseUrl overrides the manager's base URL when set
+	BaseUrl string `json:"baseUrl,omitempty"`
+	// Type is "managed" or "unmanaged"
+	Type string `json:"type"`
+}
+
+// Vault stores tenant credentials. Implementations must be safe for concurrent use
+type Vault interface {
+	Get(tenant string) (cred Credential, ok bool, err error)
+	Put(tenant string, cred Credential) error
+	Delete(tenant string) error
+	// Tenants returns the registered tenant IDs in sorted order
+	Tenants() ([]string, error)
+}
+
+// MemoryVault is a Vault kept in memory only
+type MemoryVault struct {
+	m store.Map[Credential]
+}
+
+// NewMemoryVault returns an empty MemoryVault
+func NewMemoryVault() *MemoryVault {
+	return &MemoryVault{}
+}
+
+func (v *MemoryVault) Get(tenant string) (Credential, bool, error) {
+	cred, ok := v.m.Get(tenant)
+	return cred, ok, nil
+}
+
+func (v *MemoryVault) Put(tenant string, cred Credential) error {
+	v.m.Put(tenant, cred)
+	return nil
+}
+
+func (v *MemoryVault) Delete(tenant string) error {
+	v.m.Delete(tenant)
+	return nil
+}
+
+func (v *MemoryVault) Tenants() ([]string, error) {
+	return v.m.Keys(), nil
+}
+
+// EncryptedFileVault is a Vault kept in a file encrypted with AES-256-GCM.
+// The decrypted credentials are held in memory and the file is rewritten on every change
+type EncryptedFileVault struct {
+	path string
+	aead cipher.AEAD
+	mem  *MemoryVault
+	mu   sync.Mutex
+}
+
+// OpenEncryptedFileVault opens the vault at path with a 32 byte key, creating
+// an empty vault if the file does not exist
+func OpenEncryptedFileVault(path string, key []byte) (*EncryptedFileVault, error) {
+	if len(key) != 32 {
+		return nil, errors.New("OpenEncryptedFileVault error: key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.New("OpenEncryptedFileVault error: " + err.Error())
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.New("OpenEncryptedFileVault error: " + err.Error())
+	}
+	v := &EncryptedFileVault{path: path, aead: aead, mem: NewMemoryVault()}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, errors.New("OpenEncryptedFileVault error: " + err.Error())
+	}
+	nonceSize := aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errors.New("OpenEncryptedFileVault error: " + path + " is truncated")
+	}
+	plain, err := aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return nil, errors.New("OpenEncryptedFileVault error: cannot decrypt " + path + ": wrong key or corrupted file")
+	}
+	var creds map[string]Credential
+	if err := json.Unmarshal(plain, &creds); err != nil {
+		return nil, errors.New("OpenEncryptedFileVault error: " + err.Error())
+	}
+	v.mem.m.Replace(creds)
+	return v, nil
+}
+
+func (v *EncryptedFileVault) Get(tenant string) (Credential, bool, error) {
+	return v.mem.Get(tenant)
+}
+
+func (v *EncryptedFileVault) Put(tenant string, cred Credential) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	previous, existed, _ := v.mem.Get(tenant)
+	v.mem.Put(tenant, cred)
+	if err := v.save(); err != nil {
+		if existed {
+			v.mem.Put(tenant, previous)
+		} else {
+			v.mem.Delete(tenant)
+		}
+		return err
+	}
+	return nil
+}
+
+func (v *EncryptedFileVault) Delete(tenant string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	previous, existed, _ := v.mem.Get(tenant)
+	if !existed {
+		return nil
+	}
+	v.mem.Delete(tenant)
+	if err := v.save(); err != nil {
+		v.mem.Put(tenant, previous)
+		return err
+	}
+	return nil
+}
+
+func (v *EncryptedFileVault) Tenants() ([]string, error) {
+	return v.mem.Tenants()
+}
+
+// save encrypts the credentials with a fresh nonce and replaces the file atomically. The caller must hold v.mu
+func (v *EncryptedFileVault) save() error {
+	plain, err := json.Marshal(v.mem.m.Copy())
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	return store.WriteFile(v.path, v.aead.Seal(nonce, nonce, plain, nil), 0600)
+}
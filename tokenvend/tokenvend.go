@@ -0,0 +1,196 @@
+// Package tokenvend serves short-lived user tokens to browsers and mobile apps,
+// so the account's API key and token never leave the backend
+package tokenvend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+// Authenticator identifies the caller of a token request, for example from a
+// session cookie or a bearer token. It returns an error if the caller is not signed in
+type Authenticator func(r *http.Request) (caller string, err error)
+
+// UserMapper returns the VoiceIt userId the caller may get tokens for
+type UserMapper func(caller string) (userId string, err error)
+
+// Response is the JSON body returned for a successful request
+type Response struct {
+	UserId    string    `json:"userId"`
+	UserToken string    `json:"userToken"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	ExpiresIn int       `json:"expiresIn"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler is an http.Handler that answers POST requests with a user token for the caller
+type Handler struct {
+	Client       voiceit2.VoiceIt2
+	Authenticate Authenticator
+	MapUser      UserMapper
+	// TTL is the lifetime of issued tokens. Defaults to five minutes
+	TTL time.Duration
+	// Timeout bounds the CreateUserToken call. Defaults to ten seconds
+	Timeout time.Duration
+	// RateLimit is the number of tokens a user can be issued per RatePeriod. Defaults to 10 per minute
+	RateLimit  int
+	RatePeriod time.Duration
+
+	mu     sync.Mutex
+	issued map[string][]time.Time
+	// swept is when users without recent issuances were last dropped from issued
+	swept time.Time
+}
+
+// New returns a Handler with default settings. client must hold the account's API key and token
+func New(client voiceit2.VoiceIt2, authenticate Authenticator, mapUser UserMapper) *Handler {
+	return &Handler{
+		Client:       client,
+		Authenticate: authenticate,
+		MapUser:      mapUser,
+		TTL:          5 * time.Minute,
+		Timeout:      10 * time.Second,
+		RateLimit:    10,
+		RatePeriod:   time.Minute,
+		issued:       map[string][]time.Time{},
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	caller, err := h.Authenticate(r)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthenticated"})
+		return
+	}
+	userId, err := h.MapUser(caller)
+	if err != nil || userId == "" {
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: "no VoiceIt user for caller"})
+		return
+	}
+
+	reserved, retryAfter, ok := h.allow(userId)
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter/time.Second)+1))
+		writeJSON(w, http.StatusTooManyRequests, errorResponse{Error: "too many token requests"})
+		return
+	}
+
+	resp, err := h.issue(r.Context(), userId)
+	if err != nil {
+		// Only issued tokens count against the rate limit
+		h.release(userId, reserved)
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: "could not create user token"})
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// allow reserves an issuance for userId if the rate limit permits it and
+// returns its time, so that concurrent requests cannot exceed the limit.
+// Otherwise it returns how long until the next token can be issued
+func (h *Handler) allow(userId string) (time.Time, time.Duration, bool) {
+	limit, period := h.RateLimit, h.RatePeriod
+	if limit <= 0 {
+		limit = 10
+	}
+	if period <= 0 {
+		period = time.Minute
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.issued == nil {
+		h.issued = map[string][]time.Time{}
+	}
+	now := time.Now()
+	if now.Sub(h.swept) >= period {
+		h.sweep(now, period)
+	}
+	recent := h.issued[userId][:0]
+	for _, t := range h.issued[userId] {
+		if now.Sub(t) < period {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= limit {
+		h.issued[userId] = recent
+		return time.Time{}, period - now.Sub(recent[0]), false
+	}
+	h.issued[userId] = append(recent, now)
+	return now, 0, true
+}
+
+// release gives back the issuance reserved at t by allow when no token was issued
+func (h *Handler) release(userId string, t time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	times := h.issued[userId]
+	for i := range times {
+		if times[i].Equal(t) {
+			h.issued[userId] = append(times[:i], times[i+1:]...)
+			return
+		}
+	}
+}
+
+// sweep drops the users whose issuances are all older than period, so users
+// who stopped asking for tokens do not stay in memory
+func (h *Handler) sweep(now time.Time, period time.Duration) {
+	for userId, times := range h.issued {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) >= period {
+			delete(h.issued, userId)
+		}
+	}
+	h.swept = now
+}
+
+// issue creates a token with CreateUserToken. The call is canceled when ctx, the
+// context of the token request, is done or Timeout has passed
+func (h *Handler) issue(ctx context.Context, userId string) (Response, error) {
+	ttl, timeout := h.TTL, h.Timeout
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	requestedAt := time.Now()
+	e, _ := voiceit2.EndpointByName("CreateUserToken")
+	cut, _, err := voiceit2.Do[structs.CreateUserTokenReturn](ctx, h.Client, e, voiceit2.Params{"userId": userId, "timeOut": strconv.Itoa(int(ttl.Seconds()))})
+	if err != nil {
+		return Response{}, err
+	}
+	if cut.ResponseCode != "SUCC" || cut.UserToken == "" {
+		return Response{}, errors.New("CreateUserToken failed with " + cut.ResponseCode + ": " + cut.Message)
+	}
+	expiresAt := requestedAt.Add(ttl).UTC()
+	return Response{UserId: userId, UserToken: cut.UserToken, ExpiresAt: expiresAt, ExpiresIn: int(ttl / time.Second)}, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,137 @@
+package tokenvend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apitest"
+)
+
+func post(h http.Handler, session string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/voiceit/token", nil)
+	if session != "" {
+		req.Header.Set("Authorization", "Bearer "+session)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	assert := assert.New(t)
+	api, client := apitest.New(t)
+	userId := api.AddUser()
+
+	h := New(client,
+		func(r *http.Request) (string, error) {
+			if r.Header.Get("Authorization") == "" {
+				return "", errors.New("no session")
+			}
+			return r.Header.Get("Authorization")[len("Bearer "):], nil
+		},
+		func(caller string) (string, error) {
+			if caller == "alice" {
+				return userId, nil
+			}
+			return "", errors.New("unknown caller")
+		})
+	h.RateLimit = 2
+
+	rec := post(h, "alice")
+	assert.Equal(200, rec.Code, rec.Body.String())
+	var resp Response
+	assert.Equal(nil, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(userId, resp.UserId)
+	assert.NotEqual("", resp.UserToken)
+	assert.Equal(300, resp.ExpiresIn)
+	assert.True(resp.ExpiresAt.After(time.Now().Add(4 * time.Minute)))
+
+	userClient := voiceit2.NewUserTokenClient(resp.UserToken)
+	userClient.BaseUrl = api.URL
+	ret, _ := userClient.GetAllFaceEnrollments(userId)
+	assert.Contains(string(ret), `"responseCode":"SUCC"`)
+
+	assert.Equal(401, post(h, "").Code)
+	assert.Equal(403, post(h, "mallory").Code)
+	// Upstream failures do not use up the rate limit
+	api.Fail("POST /users/"+userId+"/token", 3, 502)
+	for i := 0; i < 3; i++ {
+		assert.Equal(502, post(h, "alice").Code)
+	}
+	assert.Equal(200, post(h, "alice").Code)
+	limited := post(h, "alice")
+	assert.Equal(429, limited.Code)
+	assert.NotEqual("", limited.Header().Get("Retry-After"))
+
+	get := httptest.NewRecorder()
+	h.ServeHTTP(get, httptest.NewRequest("GET", "/voiceit/token", nil))
+	assert.Equal(405, get.Code)
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	client := voiceit2.NewClient("key", "tok")
+	client.BaseUrl = slow.URL
+
+	h := New(client,
+		func(r *http.Request) (string, error) { return "alice", nil },
+		func(caller string) (string, error) { return "usr_1", nil })
+	h.Timeout = 20 * time.Millisecond
+
+	started := time.Now()
+	rec := post(h, "alice")
+	assert.Equal(t, 502, rec.Code)
+	assert.True(t, time.Since(started) < 150*time.Millisecond)
+}
+
+func TestHandlerCanceled(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	client := voiceit2.NewClient("key", "tok")
+	client.BaseUrl = slow.URL
+
+	h := New(client,
+		func(r *http.Request) (string, error) { return "alice", nil },
+		func(caller string) (string, error) { return "usr_1", nil })
+
+	// The browser going away cancels the CreateUserToken call
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest("POST", "/voiceit/token", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	started := time.Now()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, 502, rec.Code)
+	assert.True(t, time.Since(started) < 150*time.Millisecond)
+}
+
+func TestHandlerForgetsIdleUsers(t *testing.T) {
+	assert := assert.New(t)
+	api, client := apitest.New(t)
+	users := map[string]string{"alice": api.AddUser(), "bob": api.AddUser()}
+
+	h := New(client,
+		func(r *http.Request) (string, error) { return r.Header.Get("Authorization")[len("Bearer "):], nil },
+		func(caller string) (string, error) { return users[caller], nil })
+	h.RatePeriod = 20 * time.Millisecond
+
+	assert.Equal(200, post(h, "alice").Code)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(200, post(h, "bob").Code)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	assert.Equal(1, len(h.issued), "alice's expired issuances are dropped")
+	assert.Contains(h.issued, users["bob"])
+}
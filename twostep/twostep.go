@@ -0,0 +1,169 @@
+// Package twostep identifies who is speaking in a group and then verifies that
+// user with a fresh sample before granting access
+package twostep
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apiutil"
+)
+
+// Reasons a Decision denies access
+const (
+	ReasonNotIdentified     = "not identified"
+	ReasonLowIdentification = "identification confidence below threshold"
+	ReasonUserMismatch      = "identified user is not the expected user"
+	ReasonNotVerified       = "not verified"
+	ReasonLowVerification   = "verification confidence below threshold"
+	ReasonReusedSample      = "verification sample is the identification sample"
+	ReasonWrongGroup        = "user was identified in another group"
+)
+
+// Step is the outcome of one API call of the flow
+type Step struct {
+	Call         string
+	ResponseCode string
+	Message      string
+	// UserId and GroupId are the identified user and its group. They are empty
+	// for the verification step
+	UserId  string
+	GroupId string
+	// Confidence is the step's confidence. For video, both the voice and the face
+	// confidence must reach the threshold, so it is the lower of the two
+	Confidence float64
+	// Response is the raw JSON reply of the API
+	Response json.RawMessage
+}
+
+// Decision is the result of a two-step attempt
+type Decision struct {
+	Granted bool
+	// UserId is the identified user, empty if nobody was identified
+	UserId string
+	// Reason explains a denial and is empty when access is granted
+	Reason         string
+	Identification Step
+	// Verification is nil when the flow stopped after identification
+	Verification *Step
+}
+
+// Attempt holds the samples of one access attempt
+type Attempt struct {
+	// IdentificationFile and VerificationFile are paths to two different recordings,
+	// photos or videos of the person, depending on the flow's modality
+	IdentificationFile string
+	VerificationFile   string
+	// ExpectedUserId, if set, is the user the caller claims to be. Access is denied
+	// when someone else is identified
+	ExpectedUserId string
+}
+
+// Flow runs identification against a group followed by verification of the identified user
+type Flow struct {
+	Client   voiceit2.VoiceIt2
+	Modality voiceit2.Modality
+	GroupId  string
+	// ContentLanguage and Phrase are sent with voice and video samples
+	ContentLanguage string
+	Phrase          string
+	// IdentificationThreshold and VerificationThreshold are the minimum
+	// confidences of each step. A step below its threshold denies access even if
+	// the API reported success. They default to 0, which only relies on the API
+	IdentificationThreshold float64
+	VerificationThreshold   float64
+}
+
+// New returns a Flow for the given modality and group
+func New(client voiceit2.VoiceIt2, modality voiceit2.Modality, groupId string) *Flow {
+	return &Flow{Client: client, Modality: modality, GroupId: groupId}
+}
+
+// setupErrors are response codes caused by a wrong flow configuration rather than
+// by the person in the samples. They are returned as errors instead of denials
+var setupErrors = map[string]bool{
+	"UNAC": true, "UNFD": true, "GNFD": true, "NFEF": true, "MISP": true,
+}
+
+// Run identifies the person in the identification sample and verifies the
+// identified user with the verification sample. Failed matches are reported as
+// a denied Decision; an error is only returned if the flow could not run
+func (f *Flow) Run(a Attempt) (*Decision, error) {
+	if !f.Modality.Valid() {
+		return nil, errors.New("Run error: unknown modality \"" + string(f.Modality) + "\"")
+	}
+	idData, err := ioutil.ReadFile(a.IdentificationFile)
+	if err != nil {
+		return nil, errors.New("Run error: " + err.Error())
+	}
+	verData, err := ioutil.ReadFile(a.VerificationFile)
+	if err != nil {
+		return nil, errors.New("Run error: " + err.Error())
+	}
+
+	d := &Decision{}
+	if d.Identification, err = f.identify(a.IdentificationFile); err != nil {
+		return nil, errors.New("Run error: " + err.Error())
+	}
+	d.UserId = d.Identification.UserId
+	switch {
+	case d.Identification.ResponseCode != "SUCC" || d.UserId == "":
+		return d.deny(ReasonNotIdentified), nil
+	case d.Identification.GroupId != "" && d.Identification.GroupId != f.GroupId:
+		// The user was identified in another group than the one asked for
+		return d.deny(ReasonWrongGroup), nil
+	case d.Identification.Confidence < f.IdentificationThreshold:
+		return d.deny(ReasonLowIdentification), nil
+	case a.ExpectedUserId != "" && a.ExpectedUserId != d.UserId:
+		return d.deny(ReasonUserMismatch), nil
+	case bytes.Equal(idData, verData):
+		return d.deny(ReasonReusedSample), nil
+	}
+
+	verification, err := f.verify(d.UserId, a.VerificationFile)
+	if err != nil {
+		return nil, errors.New("Run error: " + err.Error())
+	}
+	d.Verification = &verification
+	switch {
+	case verification.ResponseCode != "SUCC":
+		return d.deny(ReasonNotVerified), nil
+	case verification.Confidence < f.VerificationThreshold:
+		return d.deny(ReasonLowVerification), nil
+	}
+	d.Granted = true
+	return d, nil
+}
+
+func (d *Decision) deny(reason string) *Decision {
+	d.Granted = false
+	d.Reason = reason
+	return d
+}
+
+func (f *Flow) identify(filePath string) (Step, error) {
+	return parseStep(apiutil.Identify(f.Client, f.Modality, f.GroupId, f.ContentLanguage, f.Phrase, filePath))
+}
+
+func (f *Flow) verify(userId string, filePath string) (Step, error) {
+	return parseStep(apiutil.Verify(f.Client, f.Modality, userId, f.ContentLanguage, f.Phrase, filePath))
+}
+
+// parseStep turns a reply into a Step. Replies that reject the sample become
+// unsuccessful steps, other failures are returned as errors
+func parseStep(call string, ret []byte, err error) (Step, error) {
+	if err != nil {
+		return Step{}, err
+	}
+	m, err := apiutil.DecodeMatch(call, ret)
+	if err != nil {
+		return Step{}, err
+	}
+	if apiErr := m.APIError(call); m.ResponseCode != "SUCC" && (setupErrors[m.ResponseCode] || apiErr.Temporary()) {
+		return Step{}, apiErr
+	}
+	return Step{Call: call, ResponseCode: m.ResponseCode, Message: m.Message, UserId: m.UserId, GroupId: m.GroupId, Confidence: m.Confidence, Response: ret}, nil
+}
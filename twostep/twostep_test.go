@@ -0,0 +1,70 @@
+package twostep
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	voiceit2 "github.com/voiceittech/VoiceIt2-Go/v2"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/apitest"
+)
+
+func TestFlow(t *testing.T) {
+	assert := assert.New(t)
+	api, myVoiceIt := apitest.New(t)
+	dir, _ := ioutil.TempDir("", "twostep")
+	defer os.RemoveAll(dir)
+	sample := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		ioutil.WriteFile(path, []byte(content), 0600)
+		return path
+	}
+
+	alice, bob := api.AddUser(), api.AddUser()
+	groupId := api.AddGroup("staff", alice, bob)
+	for i, userId := range []string{alice, bob} {
+		for j := 0; j < 3; j++ {
+			path := sample(userId+"-enroll.wav", []string{"alice", "bob"}[i]+"\nenrollment")
+			_, err := myVoiceIt.CreateVoiceEnrollment(userId, "en-US", "never forget tomorrow is a new day", path)
+			assert.Equal(nil, err)
+		}
+	}
+
+	f := New(myVoiceIt, voiceit2.Voice, groupId)
+	f.ContentLanguage = "en-US"
+	f.Phrase = "never forget tomorrow is a new day"
+	first, second := sample("first.wav", "bob\nfirst"), sample("second.wav", "bob\nsecond")
+
+	d, err := f.Run(Attempt{IdentificationFile: first, VerificationFile: second})
+	assert.Equal(nil, err)
+	assert.True(d.Granted, d.Reason)
+	assert.Equal(bob, d.UserId)
+	assert.Equal("SUCC", d.Identification.ResponseCode)
+	assert.Equal(95.0, d.Verification.Confidence)
+	assert.Contains(string(d.Verification.Response), `"responseCode":"SUCC"`)
+
+	d, _ = f.Run(Attempt{IdentificationFile: first, VerificationFile: second, ExpectedUserId: alice})
+	assert.Equal(ReasonUserMismatch, d.Reason)
+	assert.Nil(d.Verification)
+
+	d, _ = f.Run(Attempt{IdentificationFile: first, VerificationFile: first})
+	assert.Equal(ReasonReusedSample, d.Reason)
+
+	d, _ = f.Run(Attempt{IdentificationFile: first, VerificationFile: sample("other.wav", "mallory\nreplay")})
+	assert.False(d.Granted)
+	assert.Equal(ReasonNotVerified, d.Reason)
+	assert.Equal("FAIL", d.Verification.ResponseCode)
+
+	d, _ = f.Run(Attempt{IdentificationFile: sample("stranger.wav", "eve\n"), VerificationFile: second})
+	assert.Equal(ReasonNotIdentified, d.Reason)
+
+	f.VerificationThreshold = 99
+	d, _ = f.Run(Attempt{IdentificationFile: first, VerificationFile: second})
+	assert.Equal(ReasonLowVerification, d.Reason)
+
+	f.GroupId = "grp_missing"
+	_, err = f.Run(Attempt{IdentificationFile: first, VerificationFile: second})
+	assert.NotEqual(nil, err, "a missing group is an error, not a denial")
+}
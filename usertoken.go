@@ -0,0 +1,156 @@
+package voiceit2
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+// NewUserTokenClient returns a new VoiceIt2 client that authenticates with a user
+// token returned by CreateUserToken. The client only has rights for the user the
+// token was created for. The token is sent as the Basic Auth user name with an empty password
+// For more details see https://api.voiceit.io/?go#user-token-generation
+func NewUserTokenClient(token string) VoiceIt2 {
+	return NewClient(token, "")
+}
+
+type cachedUserToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// userTokenCall is a CreateUserToken call in progress, shared by the callers
+// that need a new token for the same user
+type userTokenCall struct {
+	done   chan struct{}
+	cached cachedUserToken
+	err    error
+}
+
+// UserTokenManager creates user tokens with a client holding the account's API key
+// and token, caches them until shortly before they expire and revokes them
+type UserTokenManager struct {
+	// TTL is the lifetime requested for new tokens
+	TTL time.Duration
+	// RefreshBefore is how long before expiry a cached token is replaced by a new one
+	RefreshBefore time.Duration
+
+	client VoiceIt2
+	mu     sync.Mutex
+	tokens map[string]cachedUserToken
+	calls  map[string]*userTokenCall
+}
+
+// NewUserTokenManager returns a UserTokenManager that creates tokens valid for ttl
+// with the given client. Cached tokens are replaced when less than a tenth of
+// their lifetime is left
+func NewUserTokenManager(client VoiceIt2, ttl time.Duration) *UserTokenManager {
+	return &UserTokenManager{
+		TTL:           ttl,
+		RefreshBefore: ttl / 10,
+		client:        client,
+		tokens:        map[string]cachedUserToken{},
+		calls:         map[string]*userTokenCall{},
+	}
+}
+
+// Token returns a user token for userId and the time it expires, reusing a cached
+// token unless it is about to expire
+func (m *UserTokenManager) Token(userId string) (string, time.Time, error) {
+	m.mu.Lock()
+	cached, ok := m.tokens[userId]
+	m.mu.Unlock()
+	if ok && time.Now().Add(m.RefreshBefore).Before(cached.expiresAt) {
+		return cached.token, cached.expiresAt, nil
+	}
+	return m.Refresh(userId)
+}
+
+// Refresh creates a new user token for userId with CreateUserToken and caches it.
+// Tokens created earlier stay valid until they expire or are revoked. Concurrent
+// refreshes for the same user share one CreateUserToken call
+func (m *UserTokenManager) Refresh(userId string) (string, time.Time, error) {
+	m.mu.Lock()
+	call, ok := m.calls[userId]
+	if !ok {
+		call = &userTokenCall{done: make(chan struct{})}
+		if m.calls == nil {
+			m.calls = map[string]*userTokenCall{}
+		}
+		m.calls[userId] = call
+	}
+	m.mu.Unlock()
+	if ok {
+		<-call.done
+		return call.cached.token, call.cached.expiresAt, call.err
+	}
+
+	call.cached, call.err = m.create(userId)
+	m.mu.Lock()
+	// A Revoke during the call dropped it, so its token is not cached
+	if m.calls[userId] == call {
+		delete(m.calls, userId)
+		if call.err == nil {
+			m.tokens[userId] = call.cached
+		}
+	}
+	m.mu.Unlock()
+	close(call.done)
+	return call.cached.token, call.cached.expiresAt, call.err
+}
+
+func (m *UserTokenManager) create(userId string) (cachedUserToken, error) {
+	// Measure the lifetime from before the request so the cached expiry is never late
+	requestedAt := time.Now()
+	ret, err := m.client.CreateUserToken(userId, m.TTL)
+	if err != nil {
+		return cachedUserToken{}, errors.New("Refresh error: " + err.Error())
+	}
+	var cut structs.CreateUserTokenReturn
+	if err := json.Unmarshal(ret, &cut); err != nil {
+		return cachedUserToken{}, errors.New("Refresh error: " + err.Error())
+	}
+	if cut.ResponseCode != "SUCC" || cut.UserToken == "" {
+		return cachedUserToken{}, errors.New("Refresh error: CreateUserToken failed with " + cut.ResponseCode + ": " + cut.Message)
+	}
+	return cachedUserToken{token: cut.UserToken, expiresAt: requestedAt.Add(m.TTL)}, nil
+}
+
+// Client returns a client authenticated with a user token for userId. It keeps the
+// base URL and other settings of the manager's client, but not its
+// CredentialsProvider, which would send the account's credentials instead
+func (m *UserTokenManager) Client(userId string) (VoiceIt2, error) {
+	token, _, err := m.Token(userId)
+	if err != nil {
+		return VoiceIt2{}, err
+	}
+	c := m.client
+	c.CredentialsProvider = nil
+	c.APIKey = token
+	c.APIToken = ""
+	return c, nil
+}
+
+// Revoke expires all tokens of userId with ExpireUserTokens and drops the cached one
+func (m *UserTokenManager) Revoke(userId string) error {
+	m.mu.Lock()
+	delete(m.tokens, userId)
+	delete(m.calls, userId)
+	m.mu.Unlock()
+
+	ret, err := m.client.ExpireUserTokens(userId)
+	if err != nil {
+		return errors.New("Revoke error: " + err.Error())
+	}
+	var eut structs.ExpireUserTokensReturn
+	if err := json.Unmarshal(ret, &eut); err != nil {
+		return errors.New("Revoke error: " + err.Error())
+	}
+	if eut.ResponseCode != "SUCC" {
+		return errors.New("Revoke error: ExpireUserTokens failed with " + eut.ResponseCode + ": " + eut.Message)
+	}
+	return nil
+}
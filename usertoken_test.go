@@ -0,0 +1,119 @@
+package voiceit2
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/voiceittech/VoiceIt2-Go/v2/internal/fakeapi"
+	"github.com/voiceittech/VoiceIt2-Go/v2/structs"
+)
+
+func TestUserTokenManager(t *testing.T) {
+	assert := assert.New(t)
+	api, myVoiceIt := newFakeClient(t)
+	userId := api.AddUser()
+
+	m := NewUserTokenManager(myVoiceIt, time.Hour)
+	token, expiresAt, err := m.Token(userId)
+	assert.Equal(nil, err)
+	assert.True(expiresAt.After(time.Now().Add(59 * time.Minute)))
+	again, _, _ := m.Token(userId)
+	assert.Equal(token, again, "Token() should reuse the cached token")
+
+	userClient := NewUserTokenClient(token)
+	userClient.BaseUrl = api.URL
+	ret, err := userClient.GetAllVoiceEnrollments(userId)
+	assert.Equal(nil, err)
+	var gave structs.GetAllVoiceEnrollmentsReturn
+	json.Unmarshal(ret, &gave)
+	assert.Equal("SUCC", gave.ResponseCode, "GetAllVoiceEnrollments() with user token message: "+gave.Message)
+
+	// A token close to expiry is replaced
+	m.RefreshBefore = 2 * time.Hour
+	refreshed, _, err := m.Token(userId)
+	assert.Equal(nil, err)
+	assert.NotEqual(token, refreshed)
+
+	assert.Equal(nil, m.Revoke(userId))
+	ret, _ = userClient.GetAllVoiceEnrollments(userId)
+	json.Unmarshal(ret, &gave)
+	assert.Equal("UNAC", gave.ResponseCode, "revoked token should be rejected")
+
+	c, err := m.Client(userId)
+	assert.Equal(nil, err)
+	assert.Equal(api.URL, c.BaseUrl)
+	assert.Equal("", c.APIToken)
+}
+
+func TestUserTokenManagerConcurrentMisses(t *testing.T) {
+	assert := assert.New(t)
+	api, myVoiceIt := newFakeClient(t)
+	userId := api.AddUser()
+
+	var creates int32
+	arrived, release := make(chan struct{}, 1), make(chan struct{})
+	myVoiceIt.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			if strings.HasSuffix(req.URL.Path, "/token") {
+				atomic.AddInt32(&creates, 1)
+				arrived <- struct{}{}
+				<-release
+			}
+			return next(req)
+		}
+	})
+
+	m := NewUserTokenManager(myVoiceIt, time.Hour)
+	tokens := make([]string, 8)
+	var wg sync.WaitGroup
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens[i], _, _ = m.Token(userId)
+		}(i)
+	}
+	<-arrived
+	// Let the other callers find the call in progress
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(int32(1), atomic.LoadInt32(&creates), "concurrent misses share one CreateUserToken call")
+	for _, token := range tokens {
+		assert.NotEqual("", token)
+		assert.Equal(tokens[0], token)
+	}
+}
+
+func TestUserTokenManagerWithProvider(t *testing.T) {
+	assert := assert.New(t)
+	api := fakeapi.New("key", "tok")
+	defer api.Close()
+	myVoiceIt := NewClientWithProvider(StaticCredentials{APIKey: "key", APIToken: "tok"})
+	myVoiceIt.BaseUrl = api.URL
+	userId := api.AddUser()
+
+	m := NewUserTokenManager(myVoiceIt, time.Hour)
+	c, err := m.Client(userId)
+	assert.Equal(nil, err)
+	token, _, _ := m.Token(userId)
+	var user, password string
+	c.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			user, password, _ = req.BasicAuth()
+			return next(req)
+		}
+	})
+	ret, err := c.GetAllVoiceEnrollments(userId)
+	assert.Equal(nil, err)
+	assert.Contains(string(ret), `"responseCode":"SUCC"`)
+	assert.Equal(token, user, "the user token is sent instead of the provider's credentials")
+	assert.Equal("", password)
+}
@@ -1,13 +1,8 @@
 package voiceit2
 
 import (
-	"bytes"
-	"errors"
-	"io/ioutil"
-	"mime/multipart"
 	"net/http"
 	"net/url"
-	"path"
 	"strconv"
 	"time"
 
@@ -22,6 +17,32 @@ type VoiceIt2 struct {
 	APIToken        string
 	BaseUrl         string
 	NotificationUrl string
+	// LivenessUrl is the base URL of the liveness verification endpoints
+	LivenessUrl string
+	// StrictMediaTypes makes file uploads fail when the file content is not a
+	// recognized media format or is the wrong kind of media for the endpoint.
+	// By default the file name and Content-Type are corrected when the content
+	// is recognized and the file is uploaded unchanged otherwise
+	StrictMediaTypes bool
+	// HTTPClient is used to send requests. If nil, a default http.Client without a timeout is used
+	HTTPClient *http.Client
+	// CredentialsProvider, if set, is asked for the API key and token on every
+	// request instead of using APIKey and APIToken. Clients derived from this one
+	// with other credentials must clear it
+	CredentialsProvider CredentialsProvider
+	// DefaultContentLanguage is used by calls whose contentLanguage argument is empty
+	DefaultContentLanguage ContentLanguage
+	// AllowUnknownContentLanguages sends content languages that are not in
+	// SupportedContentLanguages unchanged instead of failing the call. Clients
+	// sent every content language unchanged before languages were validated;
+	// set it to keep that behavior, for example for languages added to the API
+	// after this release
+	AllowUnknownContentLanguages bool
+	// AuditSink, if set, receives the outcome of every verification and
+	// identification. When it fails, the call returns the reply with an error
+	AuditSink AuditSink
+	// Middleware wraps the sending of every request, see Use
+	Middleware []Middleware
 }
 
 // NewClient returns a new VoiceIt2 client
@@ -30,10 +51,19 @@ func NewClient(key string, tok string) VoiceIt2 {
 		APIKey:          key,
 		APIToken:        tok,
 		BaseUrl:         "https://api.voiceit.io",
+		LivenessUrl:     "https://liveness.voiceit.io",
 		NotificationUrl: "",
 	}
 }
 
+// httpClient returns the client requests are sent with
+func (vi VoiceIt2) httpClient() *http.Client {
+	if vi.HTTPClient != nil {
+		return vi.HTTPClient
+	}
+	return &http.Client{}
+}
+
 // AddNotificationUrl adds a notification URL field in the VoiceIt2 object.
 // If one is already specified, it will be overwritten
 // For more details, see https://api.voiceit.io/#webhook-notification
@@ -49,418 +79,104 @@ func (vi *VoiceIt2) RemoveNotificationUrl() {
 // GetAllUsers returns a list of all users associated with the API Key
 // For more details see https://api.voiceit.io/#get-all-users
 func (vi VoiceIt2) GetAllUsers() ([]byte, error) {
-	req, err := http.NewRequest("GET", vi.BaseUrl+"/users"+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("GetAllUsers error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("GetAllUsers error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("GetAllUsers error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["GetAllUsers"], nil)
 }
 
 // CreateUser creates a new user profile and returns a unique userId
 // that is used for all future calls related to the user profile
 // For more details see https://api.voiceit.io/#create-a-user
 func (vi VoiceIt2) CreateUser() ([]byte, error) {
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/users"+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("CreateUser error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("CreateUser error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("CreateUser error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["CreateUser"], nil)
 }
 
 // CheckUserExists takes the userId generated during a createUser and returns
 // an object which contains the boolean "exists" which shows whether a given user exists
 // For more details see https://api.voiceit.io/#check-if-a-specific-user-exists
 func (vi VoiceIt2) CheckUserExists(userId string) ([]byte, error) {
-	req, err := http.NewRequest("GET", vi.BaseUrl+"/users/"+userId+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("CheckUserExists error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("CheckUserExists error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("CheckUserExists error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["CheckUserExists"], Params{"userId": userId})
 }
 
 // DeleteUser takes the userId generated during a createUser and deletes
 // the user profile and all associated face and voice enrollments
 // For more details see https://api.voiceit.io/#delete-a-specific-user
 func (vi VoiceIt2) DeleteUser(userId string) ([]byte, error) {
-	req, err := http.NewRequest("DELETE", vi.BaseUrl+"/users/"+userId+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("DeleteUser error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("DeleteUser error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("DeleteUser error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["DeleteUser"], Params{"userId": userId})
 }
 
 // GetGroupsForUser takes the userId generated during a createUser and returns
 // a list of all groups that the user belongs to
 // For more details see https://api.voiceit.io/#get-groups-for-user
 func (vi VoiceIt2) GetGroupsForUser(userId string) ([]byte, error) {
-	req, err := http.NewRequest("GET", vi.BaseUrl+"/users/"+userId+"/groups"+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("GetGroupsForUser error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("GetGroupsForUser error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("GetGroupsForUser error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["GetGroupsForUser"], Params{"userId": userId})
 }
 
 // GetAllGroups returns a list of all groups associated with the API Key
 // For more details see https://api.voiceit.io/#get-all-groups
 func (vi VoiceIt2) GetAllGroups() ([]byte, error) {
-	req, err := http.NewRequest("GET", vi.BaseUrl+"/groups"+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("GetAllGroups error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("GetAllGroups error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("GetAllGroups error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["GetAllGroups"], nil)
 }
 
 // GetGroup takes the groupId generated during a createGroup
 // and returns the group along with a list of associated users in the group
 // For more details see https://api.voiceit.io/#get-a-specific-group
 func (vi VoiceIt2) GetGroup(groupId string) ([]byte, error) {
-	req, err := http.NewRequest("GET", vi.BaseUrl+"/groups/"+groupId+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("GetGroup error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("GetGroup error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("GetGroup error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["GetGroup"], Params{"groupId": groupId})
 }
 
 // CheckGroupExists takes the groupId generated during a createGroup
 // and returns whether the group exists for the given groupId
 // For more details see https://api.voiceit.io/#check-if-group-exists
 func (vi VoiceIt2) CheckGroupExists(groupId string) ([]byte, error) {
-	req, err := http.NewRequest("GET", vi.BaseUrl+"/groups/"+groupId+"/exists"+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("CheckGroupExists error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("CheckGroupExists error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("CheckGroupExists error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["CheckGroupExists"], Params{"groupId": groupId})
 }
 
 // CreateGroup creates a new group profile and returns a unique groupId
 // that is used for all future calls related to the group
 // For more details see https://api.voiceit.io/#create-a-group
 func (vi VoiceIt2) CreateGroup(description string) ([]byte, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("description", description); err != nil {
-		return []byte{}, errors.New("CreateGroup error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/groups"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("CreateGroup error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("CreateGroup error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("CreateGroup error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["CreateGroup"], Params{"description": description})
 }
 
 // AddUserToGroup takes the groupId generated during a createGroup
 // and the userId generated during createUser and adds the user to the group
 // For more details see https://api.voiceit.io/#add-user-to-group
 func (vi VoiceIt2) AddUserToGroup(groupId string, userId string) ([]byte, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("groupId", groupId); err != nil {
-		return []byte{}, errors.New("AddUserToGroup error: " + err.Error())
-	}
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("AddUserToGroup error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("PUT", vi.BaseUrl+"/groups/addUser"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("AddUserToGroup error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("AddUserToGroup error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("AddUserToGroup error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["AddUserToGroup"], Params{"groupId": groupId, "userId": userId})
 }
 
 // RemoveUserFromGroup takes the groupId generated during a createGroup
 // and the userId generated during createUser and removes the user from the group
 // For more details see https://api.voiceit.io/#remove-user-from-group
 func (vi VoiceIt2) RemoveUserFromGroup(groupId string, userId string) ([]byte, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("groupId", groupId); err != nil {
-		return []byte{}, errors.New("RemoveUserFromGroup error: " + err.Error())
-	}
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("RemoveUserFromGroup error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("PUT", vi.BaseUrl+"/groups/removeUser"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("RemoveUserFromGroup error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("RemoveUserFromGroup error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("RemoveUserFromGroup error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["RemoveUserFromGroup"], Params{"groupId": groupId, "userId": userId})
 }
 
 // DeleteGroup takes the groupId generated during a createGroup and deletes
 // the group profile disassociates all users associated with it
 // For more details see https://api.voiceit.io/#delete-a-specific-group
 func (vi VoiceIt2) DeleteGroup(groupId string) ([]byte, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	writer.Close()
-
-	req, err := http.NewRequest("DELETE", vi.BaseUrl+"/groups/"+groupId+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("DeleteGroup error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("DeleteGroup error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("DeleteGroup error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["DeleteGroup"], Params{"groupId": groupId})
 }
 
 // GetAllVoiceEnrollments takes the userId generated during a createUser
 // and returns a list of all voice enrollments for the user
 // For more details see https://api.voiceit.io/#get-voice-enrollments
 func (vi VoiceIt2) GetAllVoiceEnrollments(userId string) ([]byte, error) {
-	req, err := http.NewRequest("GET", vi.BaseUrl+"/enrollments/voice/"+userId+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("GetAllVoiceEnrollments error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("GetAllVoiceEnrollments error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("GetAllVoiceEnrollments error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["GetAllVoiceEnrollments"], Params{"userId": userId})
 }
 
 // GetAllVideoEnrollments takes the userId generated during a createUser
 // and returns a list of all video enrollments for the user
 // For more details see https://api.voiceit.io/#get-video-enrollments
 func (vi VoiceIt2) GetAllVideoEnrollments(userId string) ([]byte, error) {
-	req, err := http.NewRequest("GET", vi.BaseUrl+"/enrollments/video/"+userId+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("GetAllVideoEnrollments error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("GetAllVideoEnrollments error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("GetAllVideoEnrollments error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["GetAllVideoEnrollments"], Params{"userId": userId})
 }
 
 // GetAllFaceEnrollments takes the userId generated during a createUser
 // and returns a list of all face enrollments for the user
 // For more details see https://api.voiceit.io/#get-face-enrollments
 func (vi VoiceIt2) GetAllFaceEnrollments(userId string) ([]byte, error) {
-	req, err := http.NewRequest("GET", vi.BaseUrl+"/enrollments/face/"+userId+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("GetAllFaceEnrollments error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("GetAllFaceEnrollments error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("GetAllFaceEnrollments error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["GetAllFaceEnrollments"], Params{"userId": userId})
 }
 
 // CreateVoiceEnrollment takes the userId generated during a createUser,
@@ -469,58 +185,7 @@ func (vi VoiceIt2) GetAllFaceEnrollments(userId string) ([]byte, error) {
 // and absolute file path for a audio recording to create a voice enrollment for the user
 // For more details see https://api.voiceit.io/#create-voice-enrollment
 func (vi VoiceIt2) CreateVoiceEnrollment(userId string, contentLanguage string, phrase string, filePath string) ([]byte, error) {
-
-	fileContents, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollment error: " + err.Error())
-	}
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("recording", path.Base(filePath))
-	if err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollment error: " + err.Error())
-	}
-
-	if _, err := part.Write(fileContents); err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollment error: " + err.Error())
-	}
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollment error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", contentLanguage); err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollment error: " + err.Error())
-	}
-
-	if err := writer.WriteField("phrase", phrase); err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollment error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/enrollments/voice"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollment error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollment error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollment error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["CreateVoiceEnrollment"], Params{"userId": userId, "contentLanguage": contentLanguage, "phrase": phrase, "filePath": filePath})
 }
 
 // CreateVoiceEnrollmentByUrl takes the userId generated during a createUser,
@@ -529,136 +194,21 @@ func (vi VoiceIt2) CreateVoiceEnrollment(userId string, contentLanguage string,
 // and a fully qualified URL to a audio recording to create a voice enrollment for the user
 // For more details see https://api.voiceit.io/#create-voice-enrollment-by-url
 func (vi VoiceIt2) CreateVoiceEnrollmentByUrl(userId string, contentLanguage string, phrase string, fileUrl string) ([]byte, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollmentByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", contentLanguage); err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollmentByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("fileUrl", fileUrl); err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollmentByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("phrase", phrase); err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollmentByUrl error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/enrollments/voice/byUrl"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollmentByUrl error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollmentByUrl error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("CreateVoiceEnrollmentByUrl error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["CreateVoiceEnrollmentByUrl"], Params{"userId": userId, "contentLanguage": contentLanguage, "phrase": phrase, "fileUrl": fileUrl})
 }
 
 // CreateFaceEnrollment takes the userId generated during a createUser and
 // absolute file path for a video recording to create a face enrollment for the user
 // For more details see https://api.voiceit.io/#create-face-enrollment
 func (vi VoiceIt2) CreateFaceEnrollment(userId string, filePath string) ([]byte, error) {
-
-	fileContents, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return []byte{}, errors.New("CreateFaceEnrollment error: " + err.Error())
-	}
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("video", path.Base(filePath))
-	if err != nil {
-		return []byte{}, errors.New("CreateFaceEnrollment error: " + err.Error())
-	}
-
-	if _, err := part.Write(fileContents); err != nil {
-		return []byte{}, errors.New("CreateFaceEnrollment error: " + err.Error())
-	}
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("CreateFaceEnrollment error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/enrollments/face"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("CreateFaceEnrollment error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("CreateFaceEnrollment error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("CreateFaceEnrollment error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["CreateFaceEnrollment"], Params{"userId": userId, "filePath": filePath})
 }
 
 // CreateFaceEnrollmentByUrl takes the userId generated during a createUser
 // and a fully qualified URL to a video recording to verify the user's face
 // For more details see https://api.voiceit.io/#create-face-enrollment-by-url
 func (vi VoiceIt2) CreateFaceEnrollmentByUrl(userId string, fileUrl string) ([]byte, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("CreateFaceEnrollmentByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("fileUrl", fileUrl); err != nil {
-		return []byte{}, errors.New("CreateFaceEnrollmentByUrl error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/enrollments/face/byUrl"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("CreateFaceEnrollmentByUrl error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("CreateFaceEnrollmentByUrl error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("CreateFaceEnrollmentByUrl error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["CreateFaceEnrollmentByUrl"], Params{"userId": userId, "fileUrl": fileUrl})
 }
 
 // CreateVideoEnrollment takes the userId generated during a createUser,
@@ -667,58 +217,7 @@ func (vi VoiceIt2) CreateFaceEnrollmentByUrl(userId string, fileUrl string) ([]b
 // and absolute file path for a video recording to create a video enrollment for the user
 // For more details see https://api.voiceit.io/#create-video-enrollment
 func (vi VoiceIt2) CreateVideoEnrollment(userId string, contentLanguage string, phrase string, filePath string) ([]byte, error) {
-
-	fileContents, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollment error: " + err.Error())
-	}
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("video", path.Base(filePath))
-	if err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollment error: " + err.Error())
-	}
-
-	if _, err := part.Write(fileContents); err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollment error: " + err.Error())
-	}
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollment error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", contentLanguage); err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollment error: " + err.Error())
-	}
-
-	if err := writer.WriteField("phrase", phrase); err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollment error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/enrollments/video"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollment error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollment error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollment error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["CreateVideoEnrollment"], Params{"userId": userId, "contentLanguage": contentLanguage, "phrase": phrase, "filePath": filePath})
 }
 
 // CreateVideoEnrollment takes the userId generated during a createUser,
@@ -727,72 +226,14 @@ func (vi VoiceIt2) CreateVideoEnrollment(userId string, contentLanguage string,
 // and a fully qualified URL to a video recording to create a video enrollment for the user
 // For more details see https://api.voiceit.io/#create-video-enrollment-by-url
 func (vi VoiceIt2) CreateVideoEnrollmentByUrl(userId string, contentLanguage string, phrase string, fileUrl string) ([]byte, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollmentByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", contentLanguage); err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollmentByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("fileUrl", fileUrl); err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollmentByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("phrase", phrase); err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollmentByUrl error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/enrollments/video/byUrl"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollmentByUrl error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollmentByUrl error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("CreateVideoEnrollmentByUrl error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["CreateVideoEnrollmentByUrl"], Params{"userId": userId, "contentLanguage": contentLanguage, "phrase": phrase, "fileUrl": fileUrl})
 }
 
 // DeleteAllEnrollments takes the userId generated during a createUser
 // and deletes all video/voice enrollments for the user
 // For more details see https://api.voiceit.io/#delete-all-enrollments-for-user
 func (vi VoiceIt2) DeleteAllEnrollments(userId string) ([]byte, error) {
-	req, err := http.NewRequest("DELETE", vi.BaseUrl+"/enrollments/"+userId+"/all"+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("DeleteAllEnrollments error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("DeleteAllEnrollments error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("DeleteAllEnrollments error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["DeleteAllEnrollments"], Params{"userId": userId})
 }
 
 // VoiceVerification takes the userId generated during a createUser,
@@ -801,58 +242,7 @@ func (vi VoiceIt2) DeleteAllEnrollments(userId string) ([]byte, error) {
 // and absolute file path for a audio recording to verify the user's voice
 // For more details see https://api.voiceit.io/#verify-a-user-s-voice
 func (vi VoiceIt2) VoiceVerification(userId string, contentLanguage string, phrase string, filePath string) ([]byte, error) {
-
-	fileContents, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return []byte{}, errors.New("VoiceVerification error: " + err.Error())
-	}
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("recording", path.Base(filePath))
-	if err != nil {
-		return []byte{}, errors.New("VoiceVerification error: " + err.Error())
-	}
-
-	if _, err := part.Write(fileContents); err != nil {
-		return []byte{}, errors.New("VoiceVerification error: " + err.Error())
-	}
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("VoiceVerification error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", contentLanguage); err != nil {
-		return []byte{}, errors.New("VoiceVerification error: " + err.Error())
-	}
-
-	if err := writer.WriteField("phrase", phrase); err != nil {
-		return []byte{}, errors.New("VoiceVerification error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/verification/voice"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("VoiceVerification error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("VoiceVerification error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("VoiceVerification error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["VoiceVerification"], Params{"userId": userId, "contentLanguage": contentLanguage, "phrase": phrase, "filePath": filePath})
 }
 
 // VoiceVerificationByUrl takes the userId generated during a createUser,
@@ -861,136 +251,21 @@ func (vi VoiceIt2) VoiceVerification(userId string, contentLanguage string, phra
 // and a fully qualified URL to a audio recording to verify the user's voice
 // For more details see https://api.voiceit.io/#verify-a-user-s-voice-by-url
 func (vi VoiceIt2) VoiceVerificationByUrl(userId string, contentLanguage string, phrase string, fileUrl string) ([]byte, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("VoiceVerificationByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", contentLanguage); err != nil {
-		return []byte{}, errors.New("VoiceVerificationByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("fileUrl", fileUrl); err != nil {
-		return []byte{}, errors.New("VoiceVerificationByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("phrase", phrase); err != nil {
-		return []byte{}, errors.New("VoiceVerificationByUrl error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/verification/voice/byUrl"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("VoiceVerificationByUrl error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("VoiceVerificationByUrl error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("VoiceVerificationByUrl error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["VoiceVerificationByUrl"], Params{"userId": userId, "contentLanguage": contentLanguage, "phrase": phrase, "fileUrl": fileUrl})
 }
 
 // FaceVerification takes the userId generated during a createUser and a
 // absolute file path for a video recording to verify the user's face
 // For more details see https://api.voiceit.io/#verify-a-user-s-face
 func (vi VoiceIt2) FaceVerification(userId string, filePath string) ([]byte, error) {
-
-	fileContents, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return []byte{}, errors.New("FaceVerification() error: " + err.Error())
-	}
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("video", path.Base(filePath))
-	if err != nil {
-		return []byte{}, errors.New("FaceVerification() error: " + err.Error())
-	}
-
-	if _, err := part.Write(fileContents); err != nil {
-		return []byte{}, errors.New("FaceVerification() error: " + err.Error())
-	}
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("FaceVerification() error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/verification/face"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("FaceVerification error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("FaceVerification error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("FaceVerification error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["FaceVerification"], Params{"userId": userId, "filePath": filePath})
 }
 
 // FaceVerificationByUrl takes the userId generated during a createUser
 // and a fully qualified URL to a video recording to verify the user's face
 // For more details see https://api.voiceit.io/#verify-a-user-s-face-by-url
 func (vi VoiceIt2) FaceVerificationByUrl(userId string, fileUrl string) ([]byte, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("fileUrl", fileUrl); err != nil {
-		return []byte{}, errors.New("FaceVerificationByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("FaceVerificationByUrl error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/verification/face/byUrl"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("FaceVerificationByUrl error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("FaceVerificationByUrl error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("FaceVerificationByUrl error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["FaceVerificationByUrl"], Params{"userId": userId, "fileUrl": fileUrl})
 }
 
 // VideoVerification takes the userId generated during a createUser,
@@ -999,58 +274,7 @@ func (vi VoiceIt2) FaceVerificationByUrl(userId string, fileUrl string) ([]byte,
 // and absolute file path for a video recording to verify the user's face and voice
 // For more details see https://api.voiceit.io/#video-verification
 func (vi VoiceIt2) VideoVerification(userId string, contentLanguage string, phrase string, filePath string) ([]byte, error) {
-
-	fileContents, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return []byte{}, errors.New("VideoVerification error: " + err.Error())
-	}
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("video", path.Base(filePath))
-	if err != nil {
-		return []byte{}, errors.New("VideoVerification error: " + err.Error())
-	}
-
-	if _, err := part.Write(fileContents); err != nil {
-		return []byte{}, errors.New("VideoVerification error: " + err.Error())
-	}
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("VideoVerification error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", contentLanguage); err != nil {
-		return []byte{}, errors.New("VideoVerification error: " + err.Error())
-	}
-
-	if err := writer.WriteField("phrase", phrase); err != nil {
-		return []byte{}, errors.New("VideoVerification error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/verification/video"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("VideoVerification error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("VideoVerification error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("VideoVerification error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["VideoVerification"], Params{"userId": userId, "contentLanguage": contentLanguage, "phrase": phrase, "filePath": filePath})
 }
 
 // VideoVerificationByUrl takes the userId generated during a createUser,
@@ -1059,47 +283,7 @@ func (vi VoiceIt2) VideoVerification(userId string, contentLanguage string, phra
 // and a fully qualified URL to a video recording to verify the user's face and voice
 // For more details see https://api.voiceit.io/#video-verification-by-url
 func (vi VoiceIt2) VideoVerificationByUrl(userId string, contentLanguage string, phrase string, fileUrl string) ([]byte, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("userId", userId); err != nil {
-		return []byte{}, errors.New("VideoVerificationByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", contentLanguage); err != nil {
-		return []byte{}, errors.New("VideoVerificationByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("fileUrl", fileUrl); err != nil {
-		return []byte{}, errors.New("VideoVerificationByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("phrase", phrase); err != nil {
-		return []byte{}, errors.New("VideoVerificationByUrl error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/verification/video/byUrl"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("VideoVerificationByUrl error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("VideoVerificationByUrl error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("VideoVerificationByUrl error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["VideoVerificationByUrl"], Params{"userId": userId, "contentLanguage": contentLanguage, "phrase": phrase, "fileUrl": fileUrl})
 }
 
 // VoiceIdentification takes the groupId generated during a createGroup,
@@ -1109,58 +293,7 @@ func (vi VoiceIt2) VideoVerificationByUrl(userId string, contentLanguage string,
 // amongst others in the group
 // For more details see https://api.voiceit.io/#identify-a-user-s-voice
 func (vi VoiceIt2) VoiceIdentification(groupId string, contentLanguage string, phrase string, filePath string) ([]byte, error) {
-
-	fileContents, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return []byte{}, errors.New("VoiceIdentification error: " + err.Error())
-	}
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("recording", path.Base(filePath))
-	if err != nil {
-		return []byte{}, errors.New("VoiceIdentification error: " + err.Error())
-	}
-
-	if _, err := part.Write(fileContents); err != nil {
-		return []byte{}, errors.New("VoiceIdentification error: " + err.Error())
-	}
-
-	if err := writer.WriteField("groupId", groupId); err != nil {
-		return []byte{}, errors.New("VoiceIdentification error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", contentLanguage); err != nil {
-		return []byte{}, errors.New("VoiceIdentification error: " + err.Error())
-	}
-
-	if err := writer.WriteField("phrase", phrase); err != nil {
-		return []byte{}, errors.New("VoiceIdentification error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/identification/voice"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("VoiceIdentification error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("VoiceIdentification error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("VoiceIdentification error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["VoiceIdentification"], Params{"groupId": groupId, "contentLanguage": contentLanguage, "phrase": phrase, "filePath": filePath})
 }
 
 // VoiceIdentificationByUrl takes the groupId generated during a createGroup,
@@ -1170,47 +303,7 @@ func (vi VoiceIt2) VoiceIdentification(groupId string, contentLanguage string, p
 // amongst others in the group
 // For more details see https://api.voiceit.io/#identify-a-user-s-voice-by-url
 func (vi VoiceIt2) VoiceIdentificationByUrl(groupId string, contentLanguage string, phrase string, fileUrl string) ([]byte, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("fileUrl", fileUrl); err != nil {
-		return []byte{}, errors.New("VoiceIdentificationByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("groupId", groupId); err != nil {
-		return []byte{}, errors.New("VoiceIdentificationByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", contentLanguage); err != nil {
-		return []byte{}, errors.New("VoiceIdentificationByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("phrase", phrase); err != nil {
-		return []byte{}, errors.New("VoiceIdentificationByUrl error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/identification/voice/byUrl"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("VoiceIdentificationByUrl error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("VoiceIdentificationByUrl error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("VoiceIdentificationByUrl error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["VoiceIdentificationByUrl"], Params{"groupId": groupId, "contentLanguage": contentLanguage, "phrase": phrase, "fileUrl": fileUrl})
 }
 
 // VideoIdentification takes the groupId generated during a createGroup,
@@ -1220,58 +313,7 @@ func (vi VoiceIt2) VoiceIdentificationByUrl(groupId string, contentLanguage stri
 // amongst others in the group
 // For more details see https://api.voiceit.io/#identify-a-user-s-voice-amp-face
 func (vi VoiceIt2) VideoIdentification(groupId string, contentLanguage string, phrase string, filePath string) ([]byte, error) {
-
-	fileContents, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return []byte{}, errors.New("VideoIdentification error: " + err.Error())
-	}
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("video", path.Base(filePath))
-	if err != nil {
-		return []byte{}, errors.New("VideoIdentification error: " + err.Error())
-	}
-
-	if _, err := part.Write(fileContents); err != nil {
-		return []byte{}, errors.New("VideoIdentification error: " + err.Error())
-	}
-
-	if err := writer.WriteField("groupId", groupId); err != nil {
-		return []byte{}, errors.New("VideoIdentification error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", contentLanguage); err != nil {
-		return []byte{}, errors.New("VideoIdentification error: " + err.Error())
-	}
-
-	if err := writer.WriteField("phrase", phrase); err != nil {
-		return []byte{}, errors.New("VideoIdentification error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/identification/video"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("VideoIdentification error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("VideoIdentification error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("VideoIdentification error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["VideoIdentification"], Params{"groupId": groupId, "contentLanguage": contentLanguage, "phrase": phrase, "filePath": filePath})
 }
 
 // VideoIdentificationByUrl takes the groupId generated during a createGroup,
@@ -1281,48 +323,7 @@ func (vi VoiceIt2) VideoIdentification(groupId string, contentLanguage string, p
 // amongst others in the group
 // For more details see https://api.voiceit.io/#identify-a-user-s-voice-amp-face-by-url
 func (vi VoiceIt2) VideoIdentificationByUrl(groupId string, contentLanguage string, phrase string, fileUrl string) ([]byte, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	err := writer.WriteField("fileUrl", fileUrl)
-	if err != nil {
-		return []byte{}, errors.New("VideoIdentificationByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("groupId", groupId); err != nil {
-		return []byte{}, errors.New("VideoIdentificationByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", contentLanguage); err != nil {
-		return []byte{}, errors.New("VideoIdentificationByUrl error: " + err.Error())
-	}
-
-	if err := writer.WriteField("phrase", phrase); err != nil {
-		return []byte{}, errors.New("VideoIdentificationByUrl error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/identification/video/byUrl"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("VideoIdentificationByUrl error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("VideoIdentificationByUrl error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("VideoIdentificationByUrl error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["VideoIdentificationByUrl"], Params{"groupId": groupId, "contentLanguage": contentLanguage, "phrase": phrase, "fileUrl": fileUrl})
 }
 
 // FaceIdentification takes the groupId generated during a createGroup,
@@ -1330,50 +331,7 @@ func (vi VoiceIt2) VideoIdentificationByUrl(groupId string, contentLanguage stri
 // amongst others in the group
 // For more details see https://api.voiceit.io/#identify-a-user-s-face
 func (vi VoiceIt2) FaceIdentification(groupId string, filePath string) ([]byte, error) {
-
-	fileContents, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return []byte{}, errors.New("FaceIdentification error: " + err.Error())
-	}
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("video", path.Base(filePath))
-	if err != nil {
-		return []byte{}, errors.New("FaceIdentification error: " + err.Error())
-	}
-
-	if _, err := part.Write(fileContents); err != nil {
-		return []byte{}, errors.New("FaceIdentification error: " + err.Error())
-	}
-
-	if err := writer.WriteField("groupId", groupId); err != nil {
-		return []byte{}, errors.New("FaceIdentification error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/identification/face"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("FaceIdentification error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("FaceIdentification error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("FaceIdentification error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["FaceIdentification"], Params{"groupId": groupId, "filePath": filePath})
 }
 
 // FaceIdentificationByUrl takes the groupId generated during a createGroup,
@@ -1381,63 +339,47 @@ func (vi VoiceIt2) FaceIdentification(groupId string, filePath string) ([]byte,
 // amongst others in the group
 // For more details see https://api.voiceit.io/#identify-a-user-s-face-by-url
 func (vi VoiceIt2) FaceIdentificationByUrl(groupId string, fileUrl string) ([]byte, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("fileUrl", fileUrl); err != nil {
-		return []byte{}, errors.New("FaceIdentificationByUrl error: " + err.Error())
-	}
+	return vi.call(endpoints["FaceIdentificationByUrl"], Params{"groupId": groupId, "fileUrl": fileUrl})
+}
 
-	if err := writer.WriteField("groupId", groupId); err != nil {
-		return []byte{}, errors.New("FaceIdentificationByUrl error: " + err.Error())
-	}
+// GetLivenessChallenge takes the userId generated during a createUser and the
+// contentLanguage, and returns a liveness challenge: the lcoId to verify with
+// and the actions the user must perform, or the words to speak, in order.
+// Each lcoId can be used for one liveness verification
+// For more details see https://api.voiceit.io/#get-liveness-challenge
+func (vi VoiceIt2) GetLivenessChallenge(userId string, contentLanguage string) ([]byte, error) {
+	return vi.call(endpoints["GetLivenessChallenge"], Params{"userId": userId, "contentLanguage": contentLanguage})
+}
 
-	writer.Close()
+// FaceLivenessVerification takes the userId generated during a createUser, the
+// lcoId of a liveness challenge and an absolute file path for a video of the
+// user performing the challenge, to verify the user's face and liveness
+// For more details see https://api.voiceit.io/#verify-a-user-s-face-with-liveness
+func (vi VoiceIt2) FaceLivenessVerification(userId string, lcoId string, filePath string) ([]byte, error) {
+	return vi.call(endpoints["FaceLivenessVerification"], Params{"userId": userId, "lcoId": lcoId, "filePath": filePath})
+}
 
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/identification/face/byUrl"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("FaceIdentificationByUrl error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
+// VideoLivenessVerification takes the userId generated during a createUser,
+// the contentLanguage, the text of a valid phrase for the developer account,
+// the lcoId of a liveness challenge and an absolute file path for a video of
+// the user performing the challenge and saying the phrase
+// For more details see https://api.voiceit.io/#verify-a-user-s-video-with-liveness
+func (vi VoiceIt2) VideoLivenessVerification(userId string, contentLanguage string, phrase string, lcoId string, filePath string) ([]byte, error) {
+	return vi.call(endpoints["VideoLivenessVerification"], Params{"userId": userId, "contentLanguage": contentLanguage, "phrase": phrase, "lcoId": lcoId, "filePath": filePath})
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("FaceIdentificationByUrl error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("FaceIdentificationByUrl error: " + err.Error())
-	}
-	return reply, nil
+// VoiceLivenessVerification takes the userId generated during a createUser,
+// the contentLanguage, the lcoId of a liveness challenge and an absolute file
+// path for an audio recording of the user speaking the challenge
+// For more details see https://api.voiceit.io/#verify-a-user-s-voice-with-liveness
+func (vi VoiceIt2) VoiceLivenessVerification(userId string, contentLanguage string, lcoId string, filePath string) ([]byte, error) {
+	return vi.call(endpoints["VoiceLivenessVerification"], Params{"userId": userId, "contentLanguage": contentLanguage, "lcoId": lcoId, "filePath": filePath})
 }
 
 // GetPhrases takes the contentLanguage
 // For more details see https://api.voiceit.io/#get-phrases
 func (vi VoiceIt2) GetPhrases(contentLanguage string) ([]byte, error) {
-	req, err := http.NewRequest("GET", vi.BaseUrl+"/phrases/"+contentLanguage+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("GetPhrases error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("GetPhrases error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("GetPhrases error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["GetPhrases"], Params{"contentLanguage": contentLanguage})
 }
 
 // CreateUserToken takes the userId (string) and a timeout (time.Duration).
@@ -1445,225 +387,36 @@ func (vi VoiceIt2) GetPhrases(contentLanguage string) ([]byte, error) {
 // The timeout controls the expiration of the user token.
 // For more details see https://api.voiceit.io/?go#user-token-generation
 func (vi VoiceIt2) CreateUserToken(userId string, timeout time.Duration) ([]byte, error) {
-
-	var req *http.Request
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/users/"+userId+"/token"+"?timeOut="+strconv.Itoa(int(timeout.Seconds())), nil)
-	if err != nil {
-		return []byte{}, errors.New("CreateUserToken error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("CreateUserToken error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("CreateUserToken error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["CreateUserToken"], Params{"userId": userId, "timeOut": strconv.Itoa(int(timeout.Seconds()))})
 }
 
 // ExpireUserTokens takes a userId (string).
 // For more details see https://api.voiceit.io/?go#user-token-expiration
 func (vi VoiceIt2) ExpireUserTokens(userId string) ([]byte, error) {
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/users/"+userId+"/expireTokens"+vi.NotificationUrl, nil)
-	if err != nil {
-		return []byte{}, errors.New("ExpireUserTokens error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("ExpireUserTokens error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("ExpireUserTokens error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["ExpireUserTokens"], Params{"userId": userId})
 }
 
 // CreateManagedSubAccount creates a managed sub-account.
 func (vi VoiceIt2) CreateManagedSubAccount(params structs.CreateSubAccountRequest) ([]byte, error) {
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("firstName", params.FirstName); err != nil {
-		return []byte{}, errors.New("CreateManagedSubAccount error: " + err.Error())
-	}
-
-	if err := writer.WriteField("lastName", params.LastName); err != nil {
-		return []byte{}, errors.New("CreateManagedSubAccount error: " + err.Error())
-	}
-
-	if err := writer.WriteField("email", params.Email); err != nil {
-		return []byte{}, errors.New("CreateManagedSubAccount error: " + err.Error())
-	}
-
-	if err := writer.WriteField("password", params.Password); err != nil {
-		return []byte{}, errors.New("CreateManagedSubAccount error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", params.ContentLanguage); err != nil {
-		return []byte{}, errors.New("CreateManagedSubAccount error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/subaccount/managed"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("CreateManagedSubAccount error: " + err.Error())
-	}
-
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("CreateManagedSubAccount error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("CreateManagedSubAccount error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["CreateManagedSubAccount"], Params{"firstName": params.FirstName, "lastName": params.LastName, "email": params.Email, "password": params.Password, "contentLanguage": params.ContentLanguage})
 }
 
 // CreateUnmanagedSubAccount creates an unmanaged sub-account.
 func (vi VoiceIt2) CreateUnmanagedSubAccount(params structs.CreateSubAccountRequest) ([]byte, error) {
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("firstName", params.FirstName); err != nil {
-		return []byte{}, errors.New("CreateUnmanagedSubAccount error: " + err.Error())
-	}
-
-	if err := writer.WriteField("lastName", params.LastName); err != nil {
-		return []byte{}, errors.New("CreateUnmanagedSubAccount error: " + err.Error())
-	}
-
-	if err := writer.WriteField("email", params.Email); err != nil {
-		return []byte{}, errors.New("CreateUnmanagedSubAccount error: " + err.Error())
-	}
-
-	if err := writer.WriteField("password", params.Password); err != nil {
-		return []byte{}, errors.New("CreateUnmanagedSubAccount error: " + err.Error())
-	}
-
-	if err := writer.WriteField("contentLanguage", params.ContentLanguage); err != nil {
-		return []byte{}, errors.New("CreateUnmanagedSubAccount error: " + err.Error())
-	}
-
-	writer.Close()
-
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/subaccount/unmanaged"+vi.NotificationUrl, body)
-	if err != nil {
-		return []byte{}, errors.New("CreateUnmanagedSubAccount error: " + err.Error())
-	}
-
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("CreateUnmanagedSubAccount error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("CreateUnmanagedSubAccount error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["CreateUnmanagedSubAccount"], Params{"firstName": params.FirstName, "lastName": params.LastName, "email": params.Email, "password": params.Password, "contentLanguage": params.ContentLanguage})
 }
 
 // RegenerateSubAccountAPIToken takes a subAccountAPIKey (string).
 func (vi VoiceIt2) RegenerateSubAccountAPIToken(subAccountAPIKey string) ([]byte, error) {
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/subaccount/"+subAccountAPIKey, nil)
-	if err != nil {
-		return []byte{}, errors.New("RegenerateSubAccountAPIToken error: " + err.Error())
-	}
-
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("RegenerateSubAccountAPIToken error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("RegenerateSubAccountAPIToken error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["RegenerateSubAccountAPIToken"], Params{"subAccountAPIKey": subAccountAPIKey})
 }
 
 // DeleteSubAccount takes a subAccountAPIKey (string).
 func (vi VoiceIt2) DeleteSubAccount(subAccountAPIKey string) ([]byte, error) {
-	req, err := http.NewRequest("DELETE", vi.BaseUrl+"/subaccount/"+subAccountAPIKey, nil)
-	if err != nil {
-		return []byte{}, errors.New("DeleteSubAccount error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("DeleteSubAccount error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("DeleteSubAccount error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["DeleteSubAccount"], Params{"subAccountAPIKey": subAccountAPIKey})
 }
 
 // SwitchSubAccountType takes a subAccountAPIKey (string)  (
 func (vi VoiceIt2) SwitchSubAccountType(subAccountAPIKey string) ([]byte, error) {
-	req, err := http.NewRequest("POST", vi.BaseUrl+"/subaccount/"+subAccountAPIKey+"/switchType", nil)
-	if err != nil {
-		return []byte{}, errors.New("SwitchSubAccountType error: " + err.Error())
-	}
-	req.SetBasicAuth(vi.APIKey, vi.APIToken)
-	req.Header.Add("platformId", PlatformId)
-	req.Header.Add("platformVersion", PlatformVersion)
-
-	client := &http.Client{
-		// Timeout: 30 * time.Second,
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return []byte{}, errors.New("SwitchSubAccountType error: " + err.Error())
-	}
-	defer resp.Body.Close()
-	reply, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.New("SwitchSubAccountType error: " + err.Error())
-	}
-	return reply, nil
+	return vi.call(endpoints["SwitchSubAccountType"], Params{"subAccountAPIKey": subAccountAPIKey})
 }